@@ -0,0 +1,38 @@
+package kafka
+
+import "time"
+
+// rebalance strategies
+const (
+	StrategyRange      = "range"
+	StrategyRoundRobin = "roundrobin"
+	StrategySticky     = "sticky"
+)
+
+// RebalanceHooks contains callbacks called on consumer group rebalance,
+// claims contains assigned partitions by topic
+type RebalanceHooks struct {
+	// OnAssigned is called when new session starts, before messages are consumed
+	OnAssigned func(claims map[string][]int32)
+	// OnRevoked is called when session ends, after handled offsets are committed
+	OnRevoked func(claims map[string][]int32)
+}
+
+// Config represents kafka consumer configuration
+type Config struct {
+	// OffsetOldest shows whether to start from the oldest offset when group has no committed offset
+	OffsetOldest bool
+	// Version specifies kafka version used by the client
+	Version string
+	// Strategy specifies partitions rebalance strategy
+	Strategy string
+	// SessionTimeout specifies consumer group session timeout
+	SessionTimeout time.Duration
+	// CommitInterval specifies how often offsets of handled messages are committed,
+	// every handled message is committed if zero
+	CommitInterval time.Duration
+	// RetryBackoff specifies delay before the failed message is consumed again
+	RetryBackoff time.Duration
+	// Hooks contains rebalance callbacks
+	Hooks RebalanceHooks
+}
@@ -0,0 +1,175 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// ErrMalformed is returned by handler when message can't be processed at all,
+// such message is skipped and its offset is committed
+var ErrMalformed = errors.New("malformed message")
+
+// Handler processes a single consumed message
+type Handler func(ctx context.Context, msg *sarama.ConsumerMessage) error
+
+// TypedHandler processes a single decoded message
+type TypedHandler[T any] func(ctx context.Context, value T) error
+
+// JSONHandler creates Handler which decodes message value as JSON into T
+func JSONHandler[T any](h TypedHandler[T]) Handler {
+	return func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+		var value T
+		if err := json.Unmarshal(msg.Value, &value); err != nil {
+			return fmt.Errorf("%w: %w", ErrMalformed, err)
+		}
+
+		return h(ctx, value)
+	}
+}
+
+// Consume consumes specified topics until context is done.
+// Message is marked only after handler successfully processed it,
+// marked offsets are committed every commit interval and when partition claim ends.
+func (k *Kafka) Consume(ctx context.Context, topics []string, h Handler) error {
+	op := "Kafka.Consume"
+
+	// errors channel must be drained, otherwise consumer will be blocked
+	go func() {
+		for err := range k.cg.Errors() {
+			k.logger.Errorf("[%s] got consumer group error: %v", op, err)
+		}
+	}()
+
+	handler := &groupHandler{
+		k: k,
+		h: h,
+	}
+
+	for {
+		// Consume returns on every rebalance, so it should be called in a loop
+		if err := k.cg.Consume(ctx, topics, handler); err != nil {
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+				return nil
+			}
+
+			return fmt.Errorf("failed to consume topics: %w", err)
+		}
+
+		if ctx.Err() != nil {
+			k.logger.Infof("[%s] consumer stopped", op)
+			return nil
+		}
+	}
+}
+
+// groupHandler implements sarama.ConsumerGroupHandler
+type groupHandler struct {
+	k *Kafka
+	h Handler
+}
+
+// Setup is run at the beginning of a new session
+func (gh *groupHandler) Setup(session sarama.ConsumerGroupSession) error {
+	gh.k.logger.Infof("[Kafka.Setup] partitions assigned: %v", session.Claims())
+
+	if gh.k.hooks.OnAssigned != nil {
+		gh.k.hooks.OnAssigned(session.Claims())
+	}
+
+	return nil
+}
+
+// Cleanup is run at the end of a session
+func (gh *groupHandler) Cleanup(session sarama.ConsumerGroupSession) error {
+	gh.k.logger.Infof("[Kafka.Cleanup] partitions revoked: %v", session.Claims())
+
+	if gh.k.hooks.OnRevoked != nil {
+		gh.k.hooks.OnRevoked(session.Claims())
+	}
+
+	return nil
+}
+
+// ConsumeClaim processes messages of the claimed partition
+func (gh *groupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
+
+	marked := false
+	commit := func() {
+		if marked {
+			session.Commit()
+			marked = false
+		}
+	}
+
+	// handled messages are committed when claim ends, so stopped consumer doesn't repeat them
+	defer commit()
+
+	// every handled message is committed if interval isn't set
+	var commits <-chan time.Time
+	if gh.k.commitInterval > 0 {
+		ticker := time.NewTicker(gh.k.commitInterval)
+		defer ticker.Stop()
+
+		commits = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-commits:
+			commit()
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+
+			if !gh.handle(ctx, msg) {
+				// session is done, message will be consumed again by the next session
+				return nil
+			}
+
+			session.MarkMessage(msg, "")
+			marked = true
+
+			if commits == nil {
+				commit()
+			}
+		}
+	}
+}
+
+// handle processes message until it's handled or session is done,
+// returns false if message wasn't handled
+func (gh *groupHandler) handle(ctx context.Context, msg *sarama.ConsumerMessage) bool {
+	op := "Kafka.handle"
+
+	for {
+		err := gh.h(ctx, msg)
+
+		switch {
+		case err == nil:
+			return true
+		case errors.Is(err, ErrMalformed):
+			gh.k.logger.Errorf("[%s] skipping malformed message: topic=%s, partition=%d, offset=%d: %v",
+				op, msg.Topic, msg.Partition, msg.Offset, err)
+
+			return true
+		}
+
+		gh.k.logger.Errorf("[%s] failed to handle message, retrying in %s: topic=%s, partition=%d, offset=%d: %v",
+			op, gh.k.retryBackoff, msg.Topic, msg.Partition, msg.Offset, err)
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(gh.k.retryBackoff):
+		}
+	}
+}
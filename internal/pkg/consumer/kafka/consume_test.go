@@ -0,0 +1,281 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/keenywheels/go-spy/pkg/logger/zap"
+)
+
+const (
+	testTopic = "topic"
+	testGroup = "group"
+)
+
+type testEvent struct {
+	ID int `json:"id"`
+}
+
+// newTestKafka creates consumer of the mock broker which serves messages from partition 0 of test topic
+func newTestKafka(t *testing.T, messages []string, cfg Config) (*Kafka, *sarama.MockBroker) {
+	t.Helper()
+
+	broker := sarama.NewMockBroker(t, 0)
+	t.Cleanup(broker.Close)
+
+	fetch := sarama.NewMockFetchResponse(t, 1)
+	for i, msg := range messages {
+		fetch.SetMessage(testTopic, 0, int64(i), sarama.StringEncoder(msg))
+	}
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(t),
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(testTopic, 0, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset(testTopic, 0, sarama.OffsetOldest, 0).
+			SetOffset(testTopic, 0, sarama.OffsetNewest, int64(len(messages))),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, testGroup, broker),
+		"HeartbeatRequest": sarama.NewMockHeartbeatResponse(t),
+		"JoinGroupRequest": sarama.NewMockJoinGroupResponse(t).
+			SetGroupProtocol(sarama.RangeBalanceStrategyName),
+		"SyncGroupRequest": sarama.NewMockSyncGroupResponse(t).
+			SetMemberAssignment(&sarama.ConsumerGroupMemberAssignment{
+				Topics: map[string][]int32{testTopic: {0}},
+			}),
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset(testGroup, testTopic, 0, -1, "", sarama.ErrNoError).
+			SetError(sarama.ErrNoError),
+		"FetchRequest":        fetch,
+		"OffsetCommitRequest": sarama.NewMockOffsetCommitResponse(t),
+		"LeaveGroupRequest":   sarama.NewMockLeaveGroupResponse(t),
+	})
+
+	cfg.OffsetOldest = true
+	cfg.RetryBackoff = time.Millisecond
+
+	k, err := New([]string{broker.Addr()}, testGroup, cfg, zap.New(zap.LogPath(filepath.Join(t.TempDir(), "app.log"))))
+	if err != nil {
+		t.Fatalf("failed to create consumer: %v", err)
+	}
+
+	return k, broker
+}
+
+// consume runs consumer until it's stopped by handler
+func consume(t *testing.T, k *Kafka, h func(ctx context.Context, value testEvent, stop func()) error) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := k.Consume(ctx, []string{testTopic}, JSONHandler(func(ctx context.Context, value testEvent) error {
+		return h(ctx, value, cancel)
+	}))
+	if err != nil {
+		t.Fatalf("failed to consume: %v", err)
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Fatal("consumer isn't stopped by handler")
+	}
+
+	if err := k.Close(); err != nil {
+		t.Fatalf("failed to close consumer: %v", err)
+	}
+}
+
+// commits returns offsets committed to the mock broker in commit order
+func commits(broker *sarama.MockBroker) []int64 {
+	var offsets []int64
+	for _, rr := range broker.History() {
+		req, ok := rr.Request.(*sarama.OffsetCommitRequest)
+		if !ok {
+			continue
+		}
+
+		offset, _, err := req.Offset(testTopic, 0)
+		if err != nil {
+			continue
+		}
+
+		offsets = append(offsets, offset)
+	}
+
+	return offsets
+}
+
+// committedOffset returns the last offset committed to the mock broker, -1 if nothing is committed
+func committedOffset(broker *sarama.MockBroker) int64 {
+	offsets := commits(broker)
+	if len(offsets) == 0 {
+		return -1
+	}
+
+	return offsets[len(offsets)-1]
+}
+
+func TestConsume(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []string
+		failures int
+		want     []int
+	}{
+		{
+			name:     "every message is handled",
+			messages: []string{`{"id":1}`, `{"id":2}`, `{"id":3}`},
+			want:     []int{1, 2, 3},
+		},
+		{
+			name:     "malformed message is skipped",
+			messages: []string{`{"id":1}`, `{"id":`, `{"id":3}`},
+			want:     []int{1, 3},
+		},
+		{
+			name:     "failed message is retried",
+			messages: []string{`{"id":1}`, `{"id":2}`, `{"id":3}`},
+			failures: 2,
+			want:     []int{1, 2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, broker := newTestKafka(t, tt.messages, Config{CommitInterval: 10 * time.Millisecond})
+
+			var (
+				got      []int
+				attempts int
+			)
+
+			last := tt.want[len(tt.want)-1]
+			consume(t, k, func(_ context.Context, value testEvent, stop func()) error {
+				attempts++
+				if attempts <= tt.failures {
+					return errors.New("temporary failure")
+				}
+
+				got = append(got, value.ID)
+				if value.ID == last {
+					stop()
+				}
+
+				return nil
+			})
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("handled %v, want %v", got, tt.want)
+			}
+
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("handled %v, want %v", got, tt.want)
+				}
+			}
+
+			// the next offset after the last handled message is committed
+			if offset := committedOffset(broker); offset != int64(len(tt.messages)) {
+				t.Fatalf("committed offset %d, want %d", offset, len(tt.messages))
+			}
+		})
+	}
+}
+
+func TestConsumeCommits(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		want     []int64
+	}{
+		{name: "every message is committed", interval: 0, want: []int64{1, 2, 3}},
+		{name: "batch is committed on stop", interval: time.Hour, want: []int64{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, broker := newTestKafka(t, []string{`{"id":1}`, `{"id":2}`, `{"id":3}`}, Config{CommitInterval: tt.interval})
+
+			consume(t, k, func(_ context.Context, value testEvent, stop func()) error {
+				if value.ID == 3 {
+					stop()
+				}
+
+				return nil
+			})
+
+			if got := commits(broker); !slices.Equal(got, tt.want) {
+				t.Fatalf("got commits %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConsumeGracefulStop(t *testing.T) {
+	k, broker := newTestKafka(t, []string{`{"id":1}`, `{"id":2}`}, Config{CommitInterval: time.Hour})
+
+	attempts := 0
+	consume(t, k, func(ctx context.Context, value testEvent, stop func()) error {
+		if value.ID == 1 {
+			return nil
+		}
+
+		// consumer is stopped while message is retried
+		attempts++
+		if attempts == 3 {
+			stop()
+			<-ctx.Done()
+		}
+
+		return errors.New("temporary failure")
+	})
+
+	if attempts != 3 {
+		t.Fatalf("message is handled %d times, want 3", attempts)
+	}
+
+	// handled message is committed on stop, failed one isn't
+	if got := commits(broker); !slices.Equal(got, []int64{1}) {
+		t.Fatalf("got commits %v, want [1]", got)
+	}
+}
+
+func TestConsumeRebalanceHooks(t *testing.T) {
+	var (
+		mu                sync.Mutex
+		assigned, revoked map[string][]int32
+	)
+
+	k, _ := newTestKafka(t, []string{`{"id":1}`}, Config{Hooks: RebalanceHooks{
+		OnAssigned: func(claims map[string][]int32) {
+			mu.Lock()
+			defer mu.Unlock()
+			assigned = claims
+		},
+		OnRevoked: func(claims map[string][]int32) {
+			mu.Lock()
+			defer mu.Unlock()
+			revoked = claims
+		},
+	}})
+
+	consume(t, k, func(_ context.Context, _ testEvent, stop func()) error {
+		stop()
+		return nil
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(assigned[testTopic]) != 1 || len(revoked[testTopic]) != 1 {
+		t.Fatalf("assigned=%v revoked=%v, want partition 0 of %s", assigned, revoked, testTopic)
+	}
+}
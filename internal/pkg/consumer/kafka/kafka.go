@@ -0,0 +1,101 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/keenywheels/go-spy/pkg/logger"
+)
+
+// default config values
+const (
+	defaultRetryBackoff = 5 * time.Second
+)
+
+// Kafka represents kafka consumer group instance
+type Kafka struct {
+	cg     sarama.ConsumerGroup
+	logger logger.Logger
+
+	retryBackoff   time.Duration
+	commitInterval time.Duration
+	hooks          RebalanceHooks
+}
+
+// New creates new kafka consumer group instance
+func New(brokers []string, group string, kafkaConfig Config, l logger.Logger) (*Kafka, error) {
+	cfg, err := newSaramaConfig(kafkaConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// create consumer group
+	cg, err := sarama.NewConsumerGroup(brokers, group, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	retryBackoff := defaultRetryBackoff
+	if kafkaConfig.RetryBackoff != 0 {
+		retryBackoff = kafkaConfig.RetryBackoff
+	}
+
+	return &Kafka{
+		cg:             cg,
+		logger:         l,
+		retryBackoff:   retryBackoff,
+		commitInterval: kafkaConfig.CommitInterval,
+		hooks:          kafkaConfig.Hooks,
+	}, nil
+}
+
+// Close stops consumer group, should be called after Consume returns
+func (k *Kafka) Close() error {
+	return k.cg.Close()
+}
+
+// newSaramaConfig creates sarama config from consumer config
+func newSaramaConfig(kafkaConfig Config) (*sarama.Config, error) {
+	cfg := sarama.NewConfig()
+
+	// basic settings, offsets of handled messages are committed by consumer itself
+	cfg.Consumer.Return.Errors = true
+	cfg.Consumer.Offsets.AutoCommit.Enable = false
+
+	// config settings
+	cfg.Consumer.Offsets.Initial = sarama.OffsetNewest
+	if kafkaConfig.OffsetOldest {
+		cfg.Consumer.Offsets.Initial = sarama.OffsetOldest
+	}
+
+	if kafkaConfig.Version != "" {
+		version, err := sarama.ParseKafkaVersion(kafkaConfig.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse kafka version: %w", err)
+		}
+
+		cfg.Version = version
+	}
+
+	if kafkaConfig.SessionTimeout != 0 {
+		cfg.Consumer.Group.Session.Timeout = kafkaConfig.SessionTimeout
+	}
+
+	switch kafkaConfig.Strategy {
+	case "", StrategyRange:
+		cfg.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRange()}
+	case StrategyRoundRobin:
+		cfg.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRoundRobin()}
+	case StrategySticky:
+		cfg.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategySticky()}
+	default:
+		return nil, fmt.Errorf("unknown rebalance strategy: %s", kafkaConfig.Strategy)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid kafka config: %w", err)
+	}
+
+	return cfg, nil
+}
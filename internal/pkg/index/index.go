@@ -0,0 +1,267 @@
+package index
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// defaultMaxDocs default limit of documents stored per site
+const defaultMaxDocs = 1000
+
+// Document represents indexed document
+type Document struct {
+	ID   uint64
+	Text string
+	Date string
+	// URL and Title of the page, empty if text isn't attributed to a page
+	URL   string
+	Title string
+	// Passages are sentence-preserving parts of the text, empty if text isn't split
+	Passages []string
+}
+
+// Hit represents document matched by search
+type Hit struct {
+	Document Document
+	Score    float64
+}
+
+// Index in-memory inverted index partitioned by site
+type Index struct {
+	mu      sync.RWMutex
+	maxDocs int
+	sites   map[string]*siteIndex
+}
+
+// siteIndex contains inverted index of the single site
+type siteIndex struct {
+	nextID uint64
+	order  []uint64
+	docs   map[uint64]*document
+
+	postings map[string]map[uint64]int
+	termFreq map[string]int
+}
+
+// document stored document with its term frequencies
+type document struct {
+	Document
+	terms map[string]int
+}
+
+// New creates new index which stores up to maxDocs documents per site
+func New(maxDocs int) *Index {
+	if maxDocs <= 0 {
+		maxDocs = defaultMaxDocs
+	}
+
+	return &Index{
+		maxDocs: maxDocs,
+		sites:   make(map[string]*siteIndex),
+	}
+}
+
+// AddDocument indexes document for specified site, document ID is assigned by index.
+// The oldest documents are evicted when limit is reached.
+func (idx *Index) AddDocument(site string, d Document) {
+	terms := make(map[string]int)
+	for _, t := range Tokenize(d.Text) {
+		terms[t]++
+	}
+
+	if len(terms) == 0 {
+		return
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	si, ok := idx.sites[site]
+	if !ok {
+		si = &siteIndex{
+			docs:     make(map[uint64]*document),
+			postings: make(map[string]map[uint64]int),
+			termFreq: make(map[string]int),
+		}
+		idx.sites[site] = si
+	}
+
+	si.nextID++
+	d.ID = si.nextID
+	doc := &document{
+		Document: d,
+		terms:    terms,
+	}
+
+	si.docs[doc.ID] = doc
+	si.order = append(si.order, doc.ID)
+
+	for t, tf := range terms {
+		p, ok := si.postings[t]
+		if !ok {
+			p = make(map[uint64]int)
+			si.postings[t] = p
+		}
+
+		p[doc.ID] = tf
+		si.termFreq[t] += tf
+	}
+
+	for len(si.order) > idx.maxDocs {
+		si.remove(si.order[0])
+		si.order = si.order[1:]
+	}
+}
+
+// Search returns up to limit documents of the site ranked by tf-idf of specified terms
+func (idx *Index) Search(site string, terms []string, limit int) []Hit {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	si, ok := idx.sites[site]
+	if !ok || limit <= 0 {
+		return nil
+	}
+
+	scores := make(map[uint64]float64)
+	for _, t := range terms {
+		p, ok := si.postings[t]
+		if !ok {
+			continue
+		}
+
+		idf := si.idf(t)
+		for id, tf := range p {
+			scores[id] += (1 + math.Log(float64(tf))) * idf
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{
+			Document: si.docs[id].Document,
+			Score:    score,
+		})
+	}
+
+	// newer documents win on equal score
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+
+		return hits[i].Document.ID > hits[j].Document.ID
+	})
+
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits
+}
+
+// TopTerms returns n most significant terms of the site
+func (idx *Index) TopTerms(site string, n int) []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	si, ok := idx.sites[site]
+	if !ok || n <= 0 {
+		return nil
+	}
+
+	type termScore struct {
+		term  string
+		score float64
+	}
+
+	scores := make([]termScore, 0, len(si.termFreq))
+	for t, tf := range si.termFreq {
+		scores = append(scores, termScore{
+			term:  t,
+			score: float64(tf) * si.idf(t),
+		})
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].score != scores[j].score {
+			return scores[i].score > scores[j].score
+		}
+
+		return scores[i].term < scores[j].term
+	})
+
+	if len(scores) > n {
+		scores = scores[:n]
+	}
+
+	terms := make([]string, 0, len(scores))
+	for _, s := range scores {
+		terms = append(terms, s.term)
+	}
+
+	return terms
+}
+
+// Size returns number of documents stored for the site
+func (idx *Index) Size(site string) int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	si, ok := idx.sites[site]
+	if !ok {
+		return 0
+	}
+
+	return len(si.docs)
+}
+
+// Tokenize splits text into lowercased terms without surrounding punctuation
+func Tokenize(text string) []string {
+	fields := strings.Fields(text)
+	terms := make([]string, 0, len(fields))
+
+	for _, f := range fields {
+		t := strings.TrimFunc(f, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+
+		if t == "" {
+			continue
+		}
+
+		terms = append(terms, strings.ToLower(t))
+	}
+
+	return terms
+}
+
+// idf returns inverse document frequency of the term
+func (si *siteIndex) idf(term string) float64 {
+	return math.Log(1 + float64(len(si.docs))/float64(len(si.postings[term])))
+}
+
+// remove deletes document from the site index
+func (si *siteIndex) remove(id uint64) {
+	doc, ok := si.docs[id]
+	if !ok {
+		return
+	}
+
+	for t, tf := range doc.terms {
+		delete(si.postings[t], id)
+		if len(si.postings[t]) == 0 {
+			delete(si.postings, t)
+		}
+
+		si.termFreq[t] -= tf
+		if si.termFreq[t] <= 0 {
+			delete(si.termFreq, t)
+		}
+	}
+
+	delete(si.docs, id)
+}
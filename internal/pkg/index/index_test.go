@@ -0,0 +1,184 @@
+package index
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "empty", text: " \n ", want: []string{}},
+		{name: "lowercased", text: "Go IS Fast", want: []string{"go", "is", "fast"}},
+		{name: "surrounding punctuation", text: `"Hello," (world)! -- ok.`, want: []string{"hello", "world", "ok"}},
+		{name: "inner punctuation is kept", text: "e-mail v1.2 don't", want: []string{"e-mail", "v1.2", "don't"}},
+		{name: "digits and unicode", text: "Привет, 2025 Мир", want: []string{"привет", "2025", "мир"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Tokenize(tt.text); !slices.Equal(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		maxDocs int
+		texts   []string
+		// want is texts of stored documents in insertion order
+		want []string
+	}{
+		{name: "documents are stored", maxDocs: 3, texts: []string{"go", "rust"}, want: []string{"go", "rust"}},
+		{name: "document without terms is skipped", maxDocs: 3, texts: []string{"go", " ... ", "rust"}, want: []string{"go", "rust"}},
+		{name: "oldest documents are evicted", maxDocs: 2, texts: []string{"go", "rust", "zig"}, want: []string{"rust", "zig"}},
+		{name: "default limit", maxDocs: 0, texts: []string{"go"}, want: []string{"go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := New(tt.maxDocs)
+			for _, text := range tt.texts {
+				idx.AddDocument("site", Document{Text: text})
+			}
+
+			if size := idx.Size("site"); size != len(tt.want) {
+				t.Fatalf("got %d documents, want %d", size, len(tt.want))
+			}
+
+			si := idx.sites["site"]
+
+			var got []string
+			for _, id := range si.order {
+				got = append(got, si.docs[id].Text)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got documents %q, want %q", got, tt.want)
+			}
+
+			// evicted terms mustn't be left in postings
+			for term := range si.postings {
+				if !slices.Contains(tt.want, term) {
+					t.Fatalf("term %q of evicted document is still indexed", term)
+				}
+			}
+		})
+	}
+}
+
+func TestAddDocumentAssignsIDs(t *testing.T) {
+	idx := New(10)
+	idx.AddDocument("first", Document{ID: 42, Text: "go"})
+	idx.AddDocument("first", Document{Text: "rust"})
+	idx.AddDocument("second", Document{Text: "zig"})
+
+	tests := []struct {
+		site string
+		term string
+		want uint64
+	}{
+		{site: "first", term: "go", want: 1},
+		{site: "first", term: "rust", want: 2},
+		// ids are assigned per site
+		{site: "second", term: "zig", want: 1},
+	}
+
+	for _, tt := range tests {
+		hits := idx.Search(tt.site, []string{tt.term}, 1)
+		if len(hits) != 1 || hits[0].Document.ID != tt.want {
+			t.Fatalf("%s/%s: got hits %+v, want document %d", tt.site, tt.term, hits, tt.want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	idx := New(10)
+	for _, text := range []string{
+		"go channels and goroutines",
+		"go go go",
+		"rust ownership",
+		"go modules",
+	} {
+		idx.AddDocument("site", Document{Text: text})
+	}
+
+	tests := []struct {
+		name  string
+		site  string
+		terms []string
+		limit int
+		// want is texts of found documents in rank order
+		want []string
+	}{
+		{name: "unknown site", site: "other", terms: []string{"go"}, limit: 10},
+		{name: "zero limit", site: "site", terms: []string{"go"}, limit: 0},
+		{name: "unknown term", site: "site", terms: []string{"java"}, limit: 10},
+		{
+			name:  "term frequency and recency",
+			site:  "site",
+			terms: []string{"go"},
+			limit: 10,
+			want:  []string{"go go go", "go modules", "go channels and goroutines"},
+		},
+		{
+			name:  "rare term outweighs common one",
+			site:  "site",
+			terms: []string{"go", "ownership"},
+			limit: 3,
+			want:  []string{"go go go", "rust ownership", "go modules"},
+		},
+		{name: "limit", site: "site", terms: []string{"go"}, limit: 1, want: []string{"go go go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, hit := range idx.Search(tt.site, tt.terms, tt.limit) {
+				got = append(got, hit.Document.Text)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopTerms(t *testing.T) {
+	idx := New(2)
+	for _, text := range []string{
+		"java java java",
+		"go go rust",
+		"go zig",
+	} {
+		idx.AddDocument("site", Document{Text: text})
+	}
+
+	tests := []struct {
+		name string
+		site string
+		n    int
+		want []string
+	}{
+		{name: "unknown site", site: "other", n: 10},
+		{name: "zero terms", site: "site", n: 0},
+		// evicted document terms aren't counted, terms with equal score are sorted by name
+		{name: "all terms", site: "site", n: 10, want: []string{"go", "rust", "zig"}},
+		{name: "limit", site: "site", n: 1, want: []string{"go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := idx.TopTerms(tt.site, tt.n); !slices.Equal(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package nlp
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize returns word in canonical form: NFC composed, lowercase, with ё replaced by е
+func Normalize(word string) string {
+	word = strings.ToLower(norm.NFC.String(word))
+	return strings.ReplaceAll(word, "ё", "е")
+}
@@ -0,0 +1,27 @@
+package nlp
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		word string
+		want string
+	}{
+		{name: "lowercase", word: "Hello", want: "hello"},
+		{name: "already normalized", word: "мир", want: "мир"},
+		{name: "cyrillic uppercase", word: "МИР", want: "мир"},
+		{name: "yo", word: "Ёлка", want: "елка"},
+		{name: "decomposed yo", word: "е\u0308ж", want: "еж"},
+		{name: "decomposed accent", word: "Cafe\u0301", want: "caf\u00e9"},
+		{name: "empty", word: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.word); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
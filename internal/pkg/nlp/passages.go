@@ -0,0 +1,71 @@
+package nlp
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Passages joins consecutive sentences into passages which are at most size characters long.
+// Sentences longer than size are split by words, words longer than size are truncated.
+func Passages(sentences []string, size int) []string {
+	if size <= 0 {
+		return append(make([]string, 0, len(sentences)), sentences...)
+	}
+
+	parts := make([]string, 0, len(sentences))
+	for _, s := range sentences {
+		if utf8.RuneCountInString(s) <= size {
+			parts = append(parts, s)
+			continue
+		}
+
+		for _, w := range strings.Fields(s) {
+			if utf8.RuneCountInString(w) > size {
+				w = string([]rune(w)[:size])
+			}
+
+			parts = append(parts, w)
+		}
+	}
+
+	return join(parts, size)
+}
+
+// Cut splits text into passages which are at most size characters long,
+// passages end at sentence ends unless sentence is longer than size
+func Cut(text string, size int) []string {
+	return Passages(Sentences(text), size)
+}
+
+// join joins consecutive parts by space into strings which are at most size characters long
+func join(parts []string, size int) []string {
+	var (
+		res []string
+		cur strings.Builder
+		n   int
+	)
+
+	for _, p := range parts {
+		pn := utf8.RuneCountInString(p)
+
+		if n > 0 && n+1+pn > size {
+			res = append(res, cur.String())
+			cur.Reset()
+			n = 0
+		}
+
+		if n > 0 {
+			cur.WriteByte(' ')
+			n++
+		}
+
+		cur.WriteString(p)
+		n += pn
+	}
+
+	if n > 0 {
+		res = append(res, cur.String())
+	}
+
+	return res
+}
@@ -0,0 +1,63 @@
+package nlp
+
+import (
+	"slices"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPassages(t *testing.T) {
+	tests := []struct {
+		name      string
+		sentences []string
+		size      int
+		want      []string
+	}{
+		{name: "no sentences", sentences: nil, size: 10, want: nil},
+		{name: "unlimited", sentences: []string{"One.", "Two."}, size: 0, want: []string{"One.", "Two."}},
+		{name: "sentences are joined", sentences: []string{"One.", "Two.", "Three."}, size: 9, want: []string{"One. Two.", "Three."}},
+		{name: "exact size", sentences: []string{"One.", "Two."}, size: 9, want: []string{"One. Two."}},
+		{
+			name:      "long sentence is split by words",
+			sentences: []string{"Short.", "This sentence is long."},
+			size:      10,
+			want:      []string{"Short.", "This", "sentence", "is long."},
+		},
+		{name: "long word is truncated", sentences: []string{"Supercalifragilistic word."}, size: 5, want: []string{"Super", "word."}},
+		{name: "size in characters", sentences: []string{"Привет.", "Мир."}, size: 12, want: []string{"Привет. Мир."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Passages(tt.sentences, tt.size)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+
+			for _, p := range got {
+				if tt.size > 0 && utf8.RuneCountInString(p) > tt.size {
+					t.Fatalf("passage %q is longer than %d", p, tt.size)
+				}
+			}
+		})
+	}
+}
+
+func TestCut(t *testing.T) {
+	text := "Go is fast. It is simple! Is it fun? Yes."
+
+	tests := []struct {
+		size int
+		want []string
+	}{
+		{size: 0, want: []string{"Go is fast.", "It is simple!", "Is it fun?", "Yes."}},
+		{size: 25, want: []string{"Go is fast. It is simple!", "Is it fun? Yes."}},
+		{size: 100, want: []string{text}},
+	}
+
+	for _, tt := range tests {
+		if got := Cut(text, tt.size); !slices.Equal(got, tt.want) {
+			t.Fatalf("size %d: got %q, want %q", tt.size, got, tt.want)
+		}
+	}
+}
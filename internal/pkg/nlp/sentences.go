@@ -0,0 +1,101 @@
+package nlp
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// closers are characters which can follow sentence terminator
+	closers = `"'»”’)]`
+	// openers are characters which can precede first word of sentence
+	openers = `"'«„“‘([`
+)
+
+// abbreviations are lowercase abbreviations which are usually followed by capitalized word,
+// abbreviations which often end sentences (etc, т.д) aren't listed
+var abbreviations = map[string]struct{}{
+	// english
+	"mr": {}, "mrs": {}, "ms": {}, "dr": {}, "prof": {}, "sr": {}, "jr": {}, "st": {}, "mt": {},
+	"gen": {}, "col": {}, "lt": {}, "sgt": {}, "capt": {}, "rev": {}, "hon": {}, "gov": {},
+	"pres": {}, "vs": {}, "cf": {}, "fig": {}, "no": {}, "vol": {}, "ch": {}, "sec": {},
+	// russian
+	"г": {}, "гг": {}, "ул": {}, "пр": {}, "просп": {}, "пер": {}, "пл": {}, "им": {},
+	"акад": {}, "проф": {}, "доц": {}, "св": {}, "тов": {}, "гр": {}, "ген": {}, "см": {},
+	"ср": {}, "рис": {}, "табл": {}, "гл": {}, "англ": {}, "рус": {}, "лат": {},
+}
+
+// Sentences splits russian or english text into sentences, whitespace inside sentences is collapsed
+func Sentences(text string) []string {
+	words := strings.Fields(text)
+	sentences := make([]string, 0)
+
+	start := 0
+	for i, w := range words {
+		if i+1 < len(words) && !isSentenceEnd(w, words[i+1]) {
+			continue
+		}
+
+		sentences = append(sentences, strings.Join(words[start:i+1], " "))
+		start = i + 1
+	}
+
+	return sentences
+}
+
+// isSentenceEnd shows whether sentence ends with word w when it's followed by next word
+func isSentenceEnd(w, next string) bool {
+	w = strings.TrimRight(w, closers)
+
+	last, _ := utf8.DecodeLastRuneInString(w)
+	switch last {
+	case '!', '?', '…':
+		return startsSentence(next)
+	case '.':
+		// ellipsis
+		if strings.HasSuffix(w, "..") {
+			return startsSentence(next)
+		}
+
+		word := strings.TrimLeft(strings.TrimSuffix(w, "."), openers)
+		return !isAbbreviation(word) && startsSentence(next)
+	}
+
+	return false
+}
+
+// isAbbreviation shows whether word followed by period is an abbreviation or initial
+func isAbbreviation(word string) bool {
+	// initials, e.g. A. S. Pushkin
+	if r, n := utf8.DecodeRuneInString(word); n == len(word) && unicode.IsUpper(r) {
+		return true
+	}
+
+	// dotted abbreviations, e.g. e.g, U.S, т.е
+	if parts := strings.Split(word, "."); len(parts) > 1 {
+		for _, p := range parts {
+			if p == "" || utf8.RuneCountInString(p) > 3 {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	_, ok := abbreviations[strings.ToLower(word)]
+	return ok
+}
+
+// startsSentence shows whether word can be the first word of sentence,
+// digits are not accepted as they usually follow abbreviations like No. or стр.
+func startsSentence(word string) bool {
+	r, _ := utf8.DecodeRuneInString(strings.TrimLeft(word, openers))
+
+	// dialogue dashes
+	if r == '—' || r == '–' || r == '-' {
+		return true
+	}
+
+	return unicode.IsUpper(r)
+}
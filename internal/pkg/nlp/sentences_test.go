@@ -0,0 +1,45 @@
+package nlp
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "empty", text: " \n\t ", want: []string{}},
+		{name: "single without terminator", text: "Hello world", want: []string{"Hello world"}},
+		{
+			name: "terminators",
+			text: "First one. Second one! Third one? Fourth one… Fifth one",
+			want: []string{"First one.", "Second one!", "Third one?", "Fourth one…", "Fifth one"},
+		},
+		{name: "whitespace is collapsed", text: "First\n\n one.\tSecond  one.", want: []string{"First one.", "Second one."}},
+		{name: "lowercase next word", text: "Version 1.2 is out. it works.", want: []string{"Version 1.2 is out. it works."}},
+		{name: "ellipsis", text: "Wait... What?", want: []string{"Wait...", "What?"}},
+		{name: "closing quote", text: `He said "stop." Then left.`, want: []string{`He said "stop."`, "Then left."}},
+		{name: "opening quote", text: `It ended. "Why?" he asked.`, want: []string{"It ended.", `"Why?" he asked.`}},
+		{name: "dialogue dash", text: "Он ушёл. — Куда? — спросила она.", want: []string{"Он ушёл.", "— Куда?", "— спросила она."}},
+		{name: "abbreviation", text: "Mr. Smith met Dr. Brown. They talked.", want: []string{"Mr. Smith met Dr. Brown.", "They talked."}},
+		{name: "initials", text: "Poems by A. S. Pushkin are read. Always.", want: []string{"Poems by A. S. Pushkin are read.", "Always."}},
+		{name: "dotted abbreviation", text: "Cities, e.g. Paris. Next one.", want: []string{"Cities, e.g. Paris.", "Next one."}},
+		{
+			name: "russian abbreviations",
+			text: "Дом на ул. Ленина, т.е. в центре. См. рис. 5. Конец.",
+			want: []string{"Дом на ул. Ленина, т.е. в центре.", "См. рис. 5.", "Конец."},
+		},
+		{name: "digit after period", text: "See No. 5 here. Done.", want: []string{"See No. 5 here.", "Done."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sentences(tt.text); !slices.Equal(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
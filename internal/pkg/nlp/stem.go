@@ -0,0 +1,44 @@
+package nlp
+
+import (
+	"unicode"
+
+	"github.com/kljensen/snowball/english"
+	"github.com/kljensen/snowball/russian"
+)
+
+// LangAuto language of every word is detected by its script
+const LangAuto = "auto"
+
+// Stem returns snowball stem of lowercase word, word is returned unchanged if language isn't supported
+func Stem(word, lang string) string {
+	if lang == LangAuto {
+		lang = DetectLanguage(word)
+	}
+
+	switch lang {
+	case LangRussian:
+		return russian.Stem(word, true)
+	case LangEnglish:
+		return english.Stem(word, true)
+	}
+
+	return word
+}
+
+// DetectLanguage returns language of word by its first letter: ru for cyrillic, en for latin
+// and empty string otherwise
+func DetectLanguage(word string) string {
+	for _, r := range word {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			return LangRussian
+		case unicode.Is(unicode.Latin, r):
+			return LangEnglish
+		case unicode.IsLetter(r):
+			return ""
+		}
+	}
+
+	return ""
+}
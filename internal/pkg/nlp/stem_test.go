@@ -0,0 +1,50 @@
+package nlp
+
+import "testing"
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		lang string
+		want string
+	}{
+		{word: "running", lang: LangEnglish, want: "run"},
+		{word: "libraries", lang: LangEnglish, want: "librari"},
+		{word: "программирование", lang: LangRussian, want: "программирован"},
+		{word: "книгами", lang: LangRussian, want: "книг"},
+		{word: "running", lang: LangAuto, want: "run"},
+		{word: "книгами", lang: LangAuto, want: "книг"},
+		// language of word doesn't match stemmer
+		{word: "книгами", lang: LangEnglish, want: "книгами"},
+		{word: "running", lang: "", want: "running"},
+		{word: "λόγος", lang: LangAuto, want: "λόγος"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang+"/"+tt.word, func(t *testing.T) {
+			if got := Stem(tt.word, tt.lang); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{word: "golang", want: LangEnglish},
+		{word: "голанг", want: LangRussian},
+		{word: "2024год", want: LangRussian},
+		{word: "λόγος", want: ""},
+		{word: "123", want: ""},
+		{word: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.word); got != tt.want {
+			t.Fatalf("DetectLanguage(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package nlp
+
+import "strings"
+
+// supported languages
+const (
+	// LangRussian russian language code
+	LangRussian = "ru"
+	// LangEnglish english language code
+	LangEnglish = "en"
+)
+
+// stopwords built-in stopword lists by language, words are normalized
+var stopwords = map[string]string{
+	LangRussian: `
+		а без более бы был была были было быть в вам вас весь во вот все всего всех вы где да даже
+		для до его ее ей ему если есть еще же за здесь и из или им их к как какая какой когда кто
+		ли либо мне может мы на над надо наш не него нее нет ни них но ну о об однако он она они
+		оно от очень по под при про с со так также такой там те тем то того тоже той только том
+		ты у уже хотя чего чей чем что чтобы эта эти это этот я себя себе свой своя свои свое
+		тот та тех теперь тогда тут куда зачем почему потому поэтому через после перед между
+		всегда никогда иногда сейчас можно нужно нельзя будет будут будем буду раз два
+		ведь вдруг опять уж чуть менее много мало вся всю всем всеми сам сама сами
+		само какие каких который которая которое которые которых кому чему ним ними нем
+		мой моя мои мое твой твоя твои наша наши ваш ваша ваши кем меня тебя тебе нас
+	`,
+	LangEnglish: `
+		a about above after again against all am an and any are as at be because been before
+		being below between both but by can could did do does doing down during each few for
+		from further had has have having he her here hers herself him himself his how i if in
+		into is it its itself just me more most my myself no nor not now of off on once only or
+		other our ours ourselves out over own same she should so some such than that the their
+		theirs them themselves then there these they this those through to too under until up
+		very was we were what when where which while who whom why will with would you your
+		yours yourself yourselves also may might must shall us let get got via per etc
+	`,
+}
+
+// Stopwords returns built-in stopwords of the language, ok is false if language isn't supported
+func Stopwords(lang string) (words []string, ok bool) {
+	list, ok := stopwords[lang]
+	if !ok {
+		return nil, false
+	}
+
+	return strings.Fields(list), true
+}
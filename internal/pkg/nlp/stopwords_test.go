@@ -0,0 +1,41 @@
+package nlp
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStopwords(t *testing.T) {
+	tests := []struct {
+		lang    string
+		ok      bool
+		present []string
+	}{
+		{lang: LangRussian, ok: true, present: []string{"и", "что", "еще"}},
+		{lang: LangEnglish, ok: true, present: []string{"the", "and", "which"}},
+		{lang: "de", ok: false},
+		{lang: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			words, ok := Stopwords(tt.lang)
+			if ok != tt.ok {
+				t.Fatalf("got ok %t, want %t", ok, tt.ok)
+			}
+
+			for _, w := range tt.present {
+				if !slices.Contains(words, w) {
+					t.Fatalf("stopword %q is missing", w)
+				}
+			}
+
+			// lists are matched against normalized words
+			for _, w := range words {
+				if Normalize(w) != w {
+					t.Fatalf("stopword %q isn't normalized", w)
+				}
+			}
+		})
+	}
+}
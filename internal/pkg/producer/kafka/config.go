@@ -1,6 +1,66 @@
 package kafka
 
+import "time"
+
+// SASL mechanisms
+const (
+	SASLPlain       = "PLAIN"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLScramSHA512 = "SCRAM-SHA-512"
+)
+
+// TLSConfig contains TLS settings for broker connection
+type TLSConfig struct {
+	// Enabled shows whether TLS is used
+	Enabled bool
+	// CAFile specifies path to CA certificate used to verify brokers
+	CAFile string
+	// CertFile specifies path to client certificate
+	CertFile string
+	// KeyFile specifies path to client key
+	KeyFile string
+	// InsecureSkipVerify disables broker certificate verification
+	InsecureSkipVerify bool
+}
+
+// SASLConfig contains SASL authentication settings
+type SASLConfig struct {
+	// Enabled shows whether SASL authentication is used
+	Enabled bool
+	// Mechanism specifies SASL mechanism: PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512
+	Mechanism string
+	// User specifies SASL user
+	User string
+	// Password specifies SASL password
+	Password string
+}
+
 // Config represents kafka broker configuration
 type Config struct {
 	MaxRetry int
+
+	// ClientID specifies client id sent to brokers
+	ClientID string
+	// Version specifies kafka version used by the client
+	Version string
+	// TLS contains TLS settings
+	TLS TLSConfig
+	// SASL contains SASL settings
+	SASL SASLConfig
+
+	// Async shows whether messages are sent in background using async producer
+	Async bool
+	// BatchSize specifies number of buffered messages which triggers flush (async mode only)
+	BatchSize int
+	// Linger specifies how long messages are buffered before flush (async mode only)
+	Linger time.Duration
+	// MaxInFlight specifies maximum number of not acknowledged messages (async mode only)
+	MaxInFlight int
+	// Compression specifies compression codec: none, gzip, snappy, lz4 or zstd
+	Compression string
+
+	// OnDelivered is called when message is acknowledged by broker (async mode only)
+	OnDelivered func(msg Message)
+	// OnFailed is called when message can't be delivered (async mode only)
+	OnFailed func(msg Message, err error)
 }
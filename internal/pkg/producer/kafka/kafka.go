@@ -1,14 +1,49 @@
 package kafka
 
-import "github.com/IBM/sarama"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/keenywheels/go-spy/pkg/logger"
+)
+
+// default config values
+const (
+	defaultMaxRetry     = 5
+	defaultMaxInFlight  = 1000
+	defaultLinger       = 100 * time.Millisecond
+	defaultCloseTimeout = 30 * time.Second
+	flushCheckInterval  = 50 * time.Millisecond
+)
+
+// ErrClosed is returned when message is produced after Close
+var ErrClosed = errors.New("kafka producer is closed")
 
 // Kafka represents kafka broker instance
 type Kafka struct {
-	p sarama.SyncProducer
+	p  sarama.SyncProducer
+	ap sarama.AsyncProducer
+
+	logger      logger.Logger
+	onDelivered func(msg Message)
+	onFailed    func(msg Message, err error)
+
+	// inFlight bounds number of not acknowledged async messages
+	inFlight chan struct{}
+	drained  sync.WaitGroup
+	// done is closed by Close, so producers waiting for in-flight slot are released
+	done chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
 }
 
 // New creates new kafka broker instance
-func New(brokers []string, kafkaConfig Config) (*Kafka, error) {
+func New(brokers []string, kafkaConfig Config, l logger.Logger) (*Kafka, error) {
 	// create cfg
 	cfg := sarama.NewConfig()
 
@@ -18,22 +53,166 @@ func New(brokers []string, kafkaConfig Config) (*Kafka, error) {
 	cfg.Producer.RequiredAcks = sarama.WaitForAll
 
 	// config settings
-	cfg.Producer.Retry.Max = 5
+	cfg.Producer.Retry.Max = defaultMaxRetry
 	if kafkaConfig.MaxRetry != 0 {
 		cfg.Producer.Retry.Max = kafkaConfig.MaxRetry
 	}
 
-	// create producer
-	producer, err := sarama.NewSyncProducer(brokers, cfg)
+	if err := applySecurity(cfg, kafkaConfig); err != nil {
+		return nil, err
+	}
+
+	if kafkaConfig.Compression != "" {
+		if err := cfg.Producer.Compression.UnmarshalText([]byte(kafkaConfig.Compression)); err != nil {
+			return nil, err
+		}
+	}
+
+	k := &Kafka{
+		logger:      l,
+		onDelivered: kafkaConfig.OnDelivered,
+		onFailed:    kafkaConfig.OnFailed,
+	}
+
+	if !kafkaConfig.Async {
+		// create producer
+		producer, err := sarama.NewSyncProducer(brokers, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		k.p = producer
+
+		return k, nil
+	}
+
+	// async settings
+	maxInFlight := defaultMaxInFlight
+	if kafkaConfig.MaxInFlight != 0 {
+		maxInFlight = kafkaConfig.MaxInFlight
+	}
+
+	// batch can't be filled when it's bigger than in-flight buffer
+	if kafkaConfig.BatchSize >= maxInFlight {
+		return nil, fmt.Errorf("batch size %d must be less than max in-flight messages %d",
+			kafkaConfig.BatchSize, maxInFlight)
+	}
+
+	cfg.Producer.Flush.Messages = kafkaConfig.BatchSize
+	cfg.Producer.Flush.Frequency = defaultLinger
+	if kafkaConfig.Linger != 0 {
+		cfg.Producer.Flush.Frequency = kafkaConfig.Linger
+	}
+
+	// create async producer
+	producer, err := sarama.NewAsyncProducer(brokers, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Kafka{
-		p: producer,
-	}, nil
+	k.startAsync(producer, maxInFlight)
+
+	return k, nil
+}
+
+// startAsync starts handling acknowledgements of async producer
+func (k *Kafka) startAsync(producer sarama.AsyncProducer, maxInFlight int) {
+	k.ap = producer
+	k.inFlight = make(chan struct{}, maxInFlight)
+	k.done = make(chan struct{})
+
+	k.drained.Add(2)
+	go k.handleSuccesses()
+	go k.handleErrors()
+}
+
+// Flush waits until all produced async messages are acknowledged or context is done
+func (k *Kafka) Flush(ctx context.Context) error {
+	if k.ap == nil {
+		return nil
+	}
+
+	ticker := time.NewTicker(flushCheckInterval)
+	defer ticker.Stop()
+
+	for len(k.inFlight) > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("failed to flush kafka producer, %d messages left: %w", len(k.inFlight), ctx.Err())
+		}
+	}
+
+	return nil
 }
 
+// Close drains outstanding messages and closes producer
 func (k *Kafka) Close() error {
-	return k.p.Close()
+	k.mu.Lock()
+	if k.closed {
+		k.mu.Unlock()
+		return nil
+	}
+
+	k.closed = true
+	k.mu.Unlock()
+
+	if k.ap == nil {
+		return k.p.Close()
+	}
+
+	close(k.done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloseTimeout)
+	defer cancel()
+
+	if err := k.Flush(ctx); err != nil {
+		k.logger.Errorf("[Kafka.Close] %v", err)
+	}
+
+	// async producer flushes buffered messages and closes successes and errors channels
+	k.ap.AsyncClose()
+	k.drained.Wait()
+
+	return nil
+}
+
+// handleSuccesses processes acknowledged async messages
+func (k *Kafka) handleSuccesses() {
+	defer k.drained.Done()
+
+	for msg := range k.ap.Successes() {
+		k.logger.Debugf("[Kafka] message delivered: topic=%s, partition=%d, offset=%d",
+			msg.Topic, msg.Partition, msg.Offset)
+
+		if k.onDelivered != nil {
+			if m, ok := msg.Metadata.(Message); ok {
+				k.onDelivered(m)
+			}
+		}
+
+		k.release()
+	}
+}
+
+// handleErrors processes failed async messages
+func (k *Kafka) handleErrors() {
+	defer k.drained.Done()
+
+	for perr := range k.ap.Errors() {
+		k.logger.Errorf("[Kafka] failed to deliver message: topic=%s: %v", perr.Msg.Topic, perr.Err)
+
+		if k.onFailed != nil {
+			if m, ok := perr.Msg.Metadata.(Message); ok {
+				k.onFailed(m, perr.Err)
+			}
+		}
+
+		k.release()
+	}
+}
+
+// release frees in-flight slot of the acknowledged message
+func (k *Kafka) release() {
+	<-k.inFlight
 }
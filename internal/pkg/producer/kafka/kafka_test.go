@@ -0,0 +1,57 @@
+package kafka
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/keenywheels/go-spy/pkg/logger/zap"
+)
+
+func TestNewSelectsProducer(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 0)
+	t.Cleanup(broker.Close)
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"ApiVersionsRequest": sarama.NewMockApiVersionsResponse(t),
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("topic", 0, broker.BrokerID()),
+		"ProduceRequest": sarama.NewMockProduceResponse(t),
+	})
+
+	tests := []struct {
+		name      string
+		cfg       Config
+		wantAsync bool
+	}{
+		{name: "sync by default", cfg: Config{}},
+		{name: "async", cfg: Config{Async: true, BatchSize: 10, MaxInFlight: 100}, wantAsync: true},
+		{name: "async with default in-flight buffer", cfg: Config{Async: true}, wantAsync: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := New([]string{broker.Addr()}, tt.cfg, zap.New(zap.LogPath(filepath.Join(t.TempDir(), "app.log"))))
+			if err != nil {
+				t.Fatalf("failed to create producer: %v", err)
+			}
+			defer k.Close()
+
+			if (k.ap != nil) != tt.wantAsync || (k.p != nil) == tt.wantAsync {
+				t.Fatalf("got sync producer %t and async producer %t, want async %t", k.p != nil, k.ap != nil, tt.wantAsync)
+			}
+
+			if tt.wantAsync {
+				want := tt.cfg.MaxInFlight
+				if want == 0 {
+					want = defaultMaxInFlight
+				}
+
+				if cap(k.inFlight) != want {
+					t.Fatalf("got in-flight buffer %d, want %d", cap(k.inFlight), want)
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package kafka
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/IBM/sarama"
 )
@@ -11,9 +13,18 @@ import (
 type Message struct {
 	Topic string
 	Value any
+
+	// Key is optional partition key, messages with the same key are sent to the same partition
+	Key string
+	// Headers contains optional message headers
+	Headers map[string]string
+	// Timestamp is optional message timestamp, broker time is used if not set
+	Timestamp time.Time
 }
 
-// ProduceJSON produces a JSON message to Kafka
+// ProduceJSON produces a JSON message to Kafka.
+// In async mode it returns as soon as message is buffered, delivery errors are reported
+// through logger and OnFailed callback.
 func (k *Kafka) ProduceJSON(msg Message) error {
 	jsonMsg, err := k.getJSON(msg.Value)
 	if err != nil {
@@ -21,8 +32,19 @@ func (k *Kafka) ProduceJSON(msg Message) error {
 	}
 
 	kafkaMsg := &sarama.ProducerMessage{
-		Topic: msg.Topic,
-		Value: jsonMsg,
+		Topic:     msg.Topic,
+		Value:     jsonMsg,
+		Headers:   getHeaders(msg.Headers),
+		Timestamp: msg.Timestamp,
+		Metadata:  msg,
+	}
+
+	if msg.Key != "" {
+		kafkaMsg.Key = sarama.StringEncoder(msg.Key)
+	}
+
+	if k.ap != nil {
+		return k.produceAsync(kafkaMsg)
 	}
 
 	_, _, err = k.p.SendMessage(kafkaMsg)
@@ -33,6 +55,28 @@ func (k *Kafka) ProduceJSON(msg Message) error {
 	return nil
 }
 
+// produceAsync passes message to async producer, blocks while in-flight buffer is full.
+// Slot is taken without lock, so Close isn't blocked behind full buffer.
+func (k *Kafka) produceAsync(msg *sarama.ProducerMessage) error {
+	select {
+	case k.inFlight <- struct{}{}:
+	case <-k.done:
+		return ErrClosed
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.closed {
+		k.release()
+		return ErrClosed
+	}
+
+	k.ap.Input() <- msg
+
+	return nil
+}
+
 // getJSON processes the JSON value of the message
 func (k *Kafka) getJSON(value any) (sarama.StringEncoder, error) {
 	bytes, err := json.Marshal(value)
@@ -42,3 +86,27 @@ func (k *Kafka) getJSON(value any) (sarama.StringEncoder, error) {
 
 	return sarama.StringEncoder(bytes), nil
 }
+
+// getHeaders converts headers map to sarama record headers sorted by key
+func getHeaders(headers map[string]string) []sarama.RecordHeader {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	res := make([]sarama.RecordHeader, 0, len(keys))
+	for _, k := range keys {
+		res = append(res, sarama.RecordHeader{
+			Key:   []byte(k),
+			Value: []byte(headers[k]),
+		})
+	}
+
+	return res
+}
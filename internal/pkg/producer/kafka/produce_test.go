@@ -0,0 +1,200 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/keenywheels/go-spy/pkg/logger/zap"
+)
+
+// deliveries records async delivery callbacks
+type deliveries struct {
+	mu        sync.Mutex
+	delivered []string
+	failed    []string
+}
+
+// newTestAsyncKafka creates async producer backed by mock producer
+func newTestAsyncKafka(t *testing.T, maxInFlight int) (*Kafka, *mocks.AsyncProducer, *deliveries) {
+	t.Helper()
+
+	cfg := mocks.NewTestConfig()
+	cfg.Producer.Return.Successes = true
+
+	ap := mocks.NewAsyncProducer(t, cfg)
+	d := &deliveries{}
+
+	k := &Kafka{
+		logger: zap.New(zap.LogPath(filepath.Join(t.TempDir(), "app.log"))),
+		onDelivered: func(msg Message) {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			d.delivered = append(d.delivered, msg.Key)
+		},
+		onFailed: func(msg Message, _ error) {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			d.failed = append(d.failed, msg.Key)
+		},
+	}
+	k.startAsync(ap, maxInFlight)
+
+	return k, ap, d
+}
+
+func TestProduceJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		msg         Message
+		wantKey     sarama.Encoder
+		wantHeaders []sarama.RecordHeader
+	}{
+		{name: "without key and headers", msg: Message{Topic: "topic", Value: 1}},
+		{
+			name:    "key and sorted headers",
+			msg:     Message{Topic: "topic", Value: 1, Key: "site", Headers: map[string]string{"run_id": "1", "content_type": "json"}},
+			wantKey: sarama.StringEncoder("site"),
+			wantHeaders: []sarama.RecordHeader{
+				{Key: []byte("content_type"), Value: []byte("json")},
+				{Key: []byte("run_id"), Value: []byte("1")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := mocks.NewSyncProducer(t, nil)
+			p.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+				if msg.Topic != tt.msg.Topic || msg.Key != tt.wantKey {
+					return fmt.Errorf("got topic %s and key %v, want %s and %v", msg.Topic, msg.Key, tt.msg.Topic, tt.wantKey)
+				}
+
+				if !reflect.DeepEqual(msg.Headers, tt.wantHeaders) {
+					return fmt.Errorf("got headers %v, want %v", msg.Headers, tt.wantHeaders)
+				}
+
+				return nil
+			})
+
+			k := &Kafka{p: p}
+			if err := k.ProduceJSON(tt.msg); err != nil {
+				t.Fatalf("failed to produce message: %v", err)
+			}
+
+			if err := p.Close(); err != nil {
+				t.Fatalf("failed to close producer: %v", err)
+			}
+		})
+	}
+}
+
+func TestProduceAsync(t *testing.T) {
+	tests := []struct {
+		name          string
+		fail          []bool
+		wantDelivered int
+		wantFailed    int
+	}{
+		{name: "delivered", fail: []bool{false, false, false}, wantDelivered: 3},
+		{name: "failed", fail: []bool{true}, wantFailed: 1},
+		{name: "mixed", fail: []bool{false, true, false}, wantDelivered: 2, wantFailed: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// in-flight buffer is smaller than number of messages, so producer waits for acknowledgements
+			k, ap, d := newTestAsyncKafka(t, 1)
+
+			for _, fail := range tt.fail {
+				if fail {
+					ap.ExpectInputAndFail(sarama.ErrOutOfBrokers)
+				} else {
+					ap.ExpectInputAndSucceed()
+				}
+			}
+
+			for range tt.fail {
+				if err := k.ProduceJSON(Message{Topic: "topic", Key: "site", Value: map[string]int{"id": 1}}); err != nil {
+					t.Fatalf("failed to produce message: %v", err)
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			if err := k.Flush(ctx); err != nil {
+				t.Fatalf("failed to flush: %v", err)
+			}
+
+			if err := k.Close(); err != nil {
+				t.Fatalf("failed to close: %v", err)
+			}
+
+			d.mu.Lock()
+			defer d.mu.Unlock()
+
+			if len(d.delivered) != tt.wantDelivered || len(d.failed) != tt.wantFailed {
+				t.Fatalf("delivered=%v failed=%v, want %d delivered and %d failed",
+					d.delivered, d.failed, tt.wantDelivered, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestProduceAsyncAfterClose(t *testing.T) {
+	k, _, _ := newTestAsyncKafka(t, 1)
+
+	if err := k.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	if err := k.ProduceJSON(Message{Topic: "topic", Value: 1}); !errors.Is(err, ErrClosed) {
+		t.Fatalf("got error %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestCloseIsNotBlockedByFullInFlightBuffer(t *testing.T) {
+	k, _, _ := newTestAsyncKafka(t, 1)
+
+	// message which isn't acknowledged yet takes the only slot
+	k.inFlight <- struct{}{}
+
+	produced := make(chan error, 1)
+	go func() {
+		produced <- k.ProduceJSON(Message{Topic: "topic", Value: 1})
+	}()
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- k.Close()
+	}()
+
+	select {
+	case err := <-produced:
+		if !errors.Is(err, ErrClosed) {
+			t.Fatalf("got error %v, want %v", err, ErrClosed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("producer waiting for in-flight slot isn't released by close")
+	}
+
+	// close waits for pending acknowledgement
+	k.release()
+
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("failed to close: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("close is blocked")
+	}
+}
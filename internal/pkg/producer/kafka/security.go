@@ -0,0 +1,150 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/IBM/sarama"
+	"github.com/xdg-go/scram"
+)
+
+// Validate checks that authentication settings are consistent
+func (cfg Config) Validate() error {
+	if cfg.TLS.Enabled {
+		if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+			return errors.New("tls: both cert file and key file must be specified")
+		}
+	}
+
+	if cfg.SASL.Enabled {
+		switch cfg.SASL.Mechanism {
+		case SASLPlain, SASLScramSHA256, SASLScramSHA512:
+		default:
+			return fmt.Errorf("sasl: unknown mechanism %q", cfg.SASL.Mechanism)
+		}
+
+		if cfg.SASL.User == "" || cfg.SASL.Password == "" {
+			return errors.New("sasl: user and password must be specified")
+		}
+	}
+
+	return nil
+}
+
+// applySecurity applies client id, version, TLS and SASL settings to sarama config
+func applySecurity(cfg *sarama.Config, kafkaConfig Config) error {
+	if err := kafkaConfig.Validate(); err != nil {
+		return fmt.Errorf("invalid kafka config: %w", err)
+	}
+
+	if kafkaConfig.ClientID != "" {
+		cfg.ClientID = kafkaConfig.ClientID
+	}
+
+	if kafkaConfig.Version != "" {
+		version, err := sarama.ParseKafkaVersion(kafkaConfig.Version)
+		if err != nil {
+			return fmt.Errorf("failed to parse kafka version: %w", err)
+		}
+
+		cfg.Version = version
+	}
+
+	if kafkaConfig.TLS.Enabled {
+		tlsCfg, err := newTLSConfig(kafkaConfig.TLS)
+		if err != nil {
+			return fmt.Errorf("failed to create tls config: %w", err)
+		}
+
+		cfg.Net.TLS.Enable = true
+		cfg.Net.TLS.Config = tlsCfg
+	}
+
+	if kafkaConfig.SASL.Enabled {
+		cfg.Net.SASL.Enable = true
+		cfg.Net.SASL.User = kafkaConfig.SASL.User
+		cfg.Net.SASL.Password = kafkaConfig.SASL.Password
+		cfg.Net.SASL.Mechanism = sarama.SASLMechanism(kafkaConfig.SASL.Mechanism)
+
+		switch kafkaConfig.SASL.Mechanism {
+		case SASLScramSHA256:
+			cfg.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+				return &scramClient{hashGen: scram.SHA256}
+			}
+		case SASLScramSHA512:
+			cfg.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+				return &scramClient{hashGen: scram.SHA512}
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid kafka config: %w", err)
+	}
+
+	return nil
+}
+
+// newTLSConfig creates tls config, reads certificates from files
+func newTLSConfig(tlsConfig TLSConfig) (*tls.Config, error) {
+	cfg := &tls.Config{
+		InsecureSkipVerify: tlsConfig.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if tlsConfig.CAFile != "" {
+		ca, err := os.ReadFile(tlsConfig.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("failed to parse ca file %s", tlsConfig.CAFile)
+		}
+
+		cfg.RootCAs = pool
+	}
+
+	if tlsConfig.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(tlsConfig.CertFile, tlsConfig.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// scramClient implements sarama.SCRAMClient
+type scramClient struct {
+	hashGen scram.HashGeneratorFcn
+	conv    *scram.ClientConversation
+}
+
+// Begin prepares client for SCRAM exchange
+func (c *scramClient) Begin(user, password, authzID string) error {
+	client, err := c.hashGen.NewClient(user, password, authzID)
+	if err != nil {
+		return err
+	}
+
+	c.conv = client.NewConversation()
+
+	return nil
+}
+
+// Step takes server challenge and returns client response
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.conv.Step(challenge)
+}
+
+// Done shows whether SCRAM exchange is complete
+func (c *scramClient) Done() bool {
+	return c.conv.Done()
+}
@@ -0,0 +1,124 @@
+package kafka
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "without auth", cfg: Config{}},
+		{name: "tls without client certificate", cfg: Config{TLS: TLSConfig{Enabled: true, CAFile: "ca.pem"}}},
+		{name: "tls client certificate", cfg: Config{TLS: TLSConfig{Enabled: true, CertFile: "cert.pem", KeyFile: "key.pem"}}},
+		{name: "tls cert without key", cfg: Config{TLS: TLSConfig{Enabled: true, CertFile: "cert.pem"}}, wantErr: true},
+		{name: "tls key without cert", cfg: Config{TLS: TLSConfig{Enabled: true, KeyFile: "key.pem"}}, wantErr: true},
+		{name: "disabled tls isn't checked", cfg: Config{TLS: TLSConfig{CertFile: "cert.pem"}}},
+		{name: "sasl plain", cfg: Config{SASL: SASLConfig{Enabled: true, Mechanism: SASLPlain, User: "user", Password: "password"}}},
+		{name: "sasl scram", cfg: Config{SASL: SASLConfig{Enabled: true, Mechanism: SASLScramSHA512, User: "user", Password: "password"}}},
+		{name: "sasl unknown mechanism", cfg: Config{SASL: SASLConfig{Enabled: true, Mechanism: "GSSAPI", User: "user", Password: "password"}}, wantErr: true},
+		{name: "sasl without password", cfg: Config{SASL: SASLConfig{Enabled: true, Mechanism: SASLPlain, User: "user"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplySecurity(t *testing.T) {
+	dir := t.TempDir()
+
+	invalidCA := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalidCA, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("failed to write ca file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+		check   func(t *testing.T, cfg *sarama.Config)
+	}{
+		{
+			name: "client id and version",
+			cfg:  Config{ClientID: "scheduler", Version: "3.6.0"},
+			check: func(t *testing.T, cfg *sarama.Config) {
+				if cfg.ClientID != "scheduler" || cfg.Version != sarama.V3_6_0_0 {
+					t.Fatalf("got client id %s and version %s", cfg.ClientID, cfg.Version)
+				}
+			},
+		},
+		{name: "invalid version", cfg: Config{Version: "latest"}, wantErr: true},
+		{
+			name: "tls",
+			cfg:  Config{TLS: TLSConfig{Enabled: true, InsecureSkipVerify: true}},
+			check: func(t *testing.T, cfg *sarama.Config) {
+				if !cfg.Net.TLS.Enable || !cfg.Net.TLS.Config.InsecureSkipVerify {
+					t.Fatal("tls isn't enabled")
+				}
+			},
+		},
+		{name: "missing ca file", cfg: Config{TLS: TLSConfig{Enabled: true, CAFile: filepath.Join(dir, "ca.pem")}}, wantErr: true},
+		{name: "invalid ca file", cfg: Config{TLS: TLSConfig{Enabled: true, CAFile: invalidCA}}, wantErr: true},
+		{
+			name:    "missing client certificate",
+			cfg:     Config{TLS: TLSConfig{Enabled: true, CertFile: filepath.Join(dir, "cert.pem"), KeyFile: filepath.Join(dir, "key.pem")}},
+			wantErr: true,
+		},
+		{
+			name: "sasl scram",
+			cfg:  Config{SASL: SASLConfig{Enabled: true, Mechanism: SASLScramSHA256, User: "user", Password: "password"}},
+			check: func(t *testing.T, cfg *sarama.Config) {
+				if !cfg.Net.SASL.Enable || cfg.Net.SASL.Mechanism != sarama.SASLTypeSCRAMSHA256 || cfg.Net.SASL.SCRAMClientGeneratorFunc == nil {
+					t.Fatal("sasl scram isn't configured")
+				}
+			},
+		},
+		{name: "invalid sasl", cfg: Config{SASL: SASLConfig{Enabled: true, Mechanism: SASLPlain}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := sarama.NewConfig()
+
+			err := applySecurity(cfg, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+
+			if tt.check != nil {
+				tt.check(t, cfg)
+			}
+		})
+	}
+}
+
+func TestNewFailsFastOnInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "invalid auth", cfg: Config{SASL: SASLConfig{Enabled: true, Mechanism: "GSSAPI"}}},
+		{name: "unknown compression", cfg: Config{Compression: "brotli"}},
+		{name: "batch isn't less than in-flight buffer", cfg: Config{Async: true, BatchSize: 10, MaxInFlight: 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// config is checked before brokers are dialed, so unreachable broker isn't reported
+			if _, err := New([]string{"127.0.0.1:0"}, tt.cfg, nil); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// budget limits names
+const (
+	BudgetPages    = "max_pages"
+	BudgetBytes    = "max_bytes"
+	BudgetWords    = "max_words"
+	BudgetDuration = "max_duration"
+)
+
+// BudgetExceededError is returned when scraping is stopped because crawl budget is exceeded
+type BudgetExceededError struct {
+	// Limit is name of exceeded limit
+	Limit string
+}
+
+// Error implements error interface
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("crawl budget exceeded: %s", e.Limit)
+}
+
+// budget tracks crawl usage against configured limits, zero limit means unlimited
+type budget struct {
+	cfg BudgetConfig
+
+	pages atomic.Int64
+	bytes atomic.Int64
+	words atomic.Int64
+
+	stop context.CancelCauseFunc
+}
+
+// start resets usage and sets function which stops scraping, returns function releasing duration timer
+func (b *budget) start(stop context.CancelCauseFunc) func() {
+	b.pages.Store(0)
+	b.bytes.Store(0)
+	b.words.Store(0)
+	b.stop = stop
+
+	if b.cfg.MaxDuration <= 0 {
+		return func() {}
+	}
+
+	timer := time.AfterFunc(b.cfg.MaxDuration, func() {
+		b.exceed(BudgetDuration)
+	})
+
+	return func() { timer.Stop() }
+}
+
+// reservePage reserves page fetch, returns false if pages limit is reached
+func (b *budget) reservePage() bool {
+	if b.cfg.MaxPages <= 0 {
+		return true
+	}
+
+	pages := b.pages.Add(1)
+	if pages > int64(b.cfg.MaxPages) {
+		b.exceed(BudgetPages)
+		return false
+	}
+
+	// last page is still fetched, but its links aren't followed
+	if pages == int64(b.cfg.MaxPages) {
+		b.exceed(BudgetPages)
+	}
+
+	return true
+}
+
+// addBytes adds fetched response bytes
+func (b *budget) addBytes(n int) {
+	if b.cfg.MaxBytes > 0 && b.bytes.Add(int64(n)) >= b.cfg.MaxBytes {
+		b.exceed(BudgetBytes)
+	}
+}
+
+// takeWords takes up to n words from budget and returns number of allowed words
+func (b *budget) takeWords(n int) int {
+	if b.cfg.MaxWords <= 0 {
+		return n
+	}
+
+	total := b.words.Add(int64(n))
+	if total < b.cfg.MaxWords {
+		return n
+	}
+
+	b.exceed(BudgetWords)
+
+	return max(0, n-int(total-b.cfg.MaxWords))
+}
+
+// exceed stops scraping with budget error
+func (b *budget) exceed(limit string) {
+	if b.stop != nil {
+		b.stop(&BudgetExceededError{Limit: limit})
+	}
+}
@@ -0,0 +1,202 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startTestBudget starts budget which cancels returned context when limit is exceeded
+func startTestBudget(t *testing.T, cfg BudgetConfig) (*budget, context.Context) {
+	t.Helper()
+
+	ctx, stop := context.WithCancelCause(context.Background())
+	t.Cleanup(func() { stop(nil) })
+
+	b := &budget{cfg: cfg}
+	t.Cleanup(b.start(stop))
+
+	return b, ctx
+}
+
+// exceededLimit returns name of limit which stopped context, empty if it isn't stopped by budget
+func exceededLimit(ctx context.Context) string {
+	var budgetErr *BudgetExceededError
+	if errors.As(context.Cause(ctx), &budgetErr) {
+		return budgetErr.Limit
+	}
+
+	return ""
+}
+
+func TestBudgetReservePage(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxPages  int
+		reserves  int
+		want      int
+		wantLimit string
+	}{
+		{name: "unlimited", maxPages: 0, reserves: 5, want: 5},
+		{name: "below limit", maxPages: 3, reserves: 2, want: 2},
+		{name: "last page is fetched", maxPages: 2, reserves: 2, want: 2, wantLimit: BudgetPages},
+		{name: "pages over limit", maxPages: 2, reserves: 4, want: 2, wantLimit: BudgetPages},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, ctx := startTestBudget(t, BudgetConfig{MaxPages: tt.maxPages})
+
+			reserved := 0
+			for range tt.reserves {
+				if b.reservePage() {
+					reserved++
+				}
+			}
+
+			if reserved != tt.want || exceededLimit(ctx) != tt.wantLimit {
+				t.Fatalf("reserved %d pages, limit %q, want %d pages, limit %q",
+					reserved, exceededLimit(ctx), tt.want, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestBudgetAddBytes(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxBytes  int64
+		adds      []int
+		wantLimit string
+	}{
+		{name: "unlimited", maxBytes: 0, adds: []int{100, 100}},
+		{name: "below limit", maxBytes: 300, adds: []int{100, 100}},
+		{name: "limit is reached", maxBytes: 200, adds: []int{100, 100}, wantLimit: BudgetBytes},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, ctx := startTestBudget(t, BudgetConfig{MaxBytes: tt.maxBytes})
+
+			for _, n := range tt.adds {
+				b.addBytes(n)
+			}
+
+			if got := exceededLimit(ctx); got != tt.wantLimit {
+				t.Fatalf("got limit %q, want %q", got, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestBudgetTakeWords(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxWords  int64
+		takes     []int
+		want      []int
+		wantLimit string
+	}{
+		{name: "unlimited", maxWords: 0, takes: []int{5, 5}, want: []int{5, 5}},
+		{name: "below limit", maxWords: 10, takes: []int{3, 4}, want: []int{3, 4}},
+		{name: "exact limit", maxWords: 10, takes: []int{10}, want: []int{10}, wantLimit: BudgetWords},
+		{name: "words are cut", maxWords: 10, takes: []int{6, 6, 2}, want: []int{6, 4, 0}, wantLimit: BudgetWords},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, ctx := startTestBudget(t, BudgetConfig{MaxWords: tt.maxWords})
+
+			got := make([]int, 0, len(tt.takes))
+			for _, n := range tt.takes {
+				got = append(got, b.takeWords(n))
+			}
+
+			if !slices.Equal(got, tt.want) || exceededLimit(ctx) != tt.wantLimit {
+				t.Fatalf("took %v words, limit %q, want %v words, limit %q", got, exceededLimit(ctx), tt.want, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestBudgetDuration(t *testing.T) {
+	_, ctx := startTestBudget(t, BudgetConfig{MaxDuration: 10 * time.Millisecond})
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("scraping isn't stopped by duration limit")
+	}
+
+	if got := exceededLimit(ctx); got != BudgetDuration {
+		t.Fatalf("got limit %q, want %q", got, BudgetDuration)
+	}
+
+	// released timer doesn't stop scraping
+	ctx, stop := context.WithCancelCause(context.Background())
+	defer stop(nil)
+
+	b := &budget{cfg: BudgetConfig{MaxDuration: 10 * time.Millisecond}}
+	b.start(stop)()
+
+	time.Sleep(50 * time.Millisecond)
+
+	if ctx.Err() != nil {
+		t.Fatal("scraping is stopped by released timer")
+	}
+}
+
+func TestCrawlBudget(t *testing.T) {
+	tests := []struct {
+		name      string
+		budget    BudgetConfig
+		wantLimit string
+		wantPaths []string
+		wantWords int
+	}{
+		{name: "pages", budget: BudgetConfig{MaxPages: 2}, wantLimit: BudgetPages, wantPaths: []string{"/", "/1"}, wantWords: 8},
+		{name: "bytes", budget: BudgetConfig{MaxBytes: 1}, wantLimit: BudgetBytes, wantPaths: []string{"/"}, wantWords: 4},
+		{name: "words", budget: BudgetConfig{MaxWords: 3}, wantLimit: BudgetWords, wantPaths: []string{"/"}, wantWords: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, log := newTestServer(t, func(w http.ResponseWriter, r *http.Request, _ int) {
+				fmt.Fprint(w, `<html><body><p>alpha beta gamma delta</p>`+
+					`<a href="/1"></a><a href="/2"></a><a href="/3"></a></body></html>`)
+			})
+
+			var (
+				mu    sync.Mutex
+				words int
+			)
+
+			s := newTestScraper(t, func(cfg *Config) {
+				cfg.Budget = tt.budget
+			})
+			s.SetOutputCallback(func(data, _ string) {
+				mu.Lock()
+				defer mu.Unlock()
+				words += len(strings.Fields(data))
+			})
+
+			var budgetErr *BudgetExceededError
+			if err := s.VisitContext(context.Background(), srv.URL+"/"); !errors.As(err, &budgetErr) || budgetErr.Limit != tt.wantLimit {
+				t.Fatalf("got error %v, want %s limit exceeded", err, tt.wantLimit)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if got := log.get(); !slices.Equal(got, tt.wantPaths) || words != tt.wantWords {
+				t.Fatalf("got requests %v and %d words, want %v and %d words", got, words, tt.wantPaths, tt.wantWords)
+			}
+		})
+	}
+}
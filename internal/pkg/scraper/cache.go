@@ -0,0 +1,204 @@
+package scraper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// linksCtxKey request context key with page links saved to cache
+const linksCtxKey = "links"
+
+// cacheEntry cached validators and links of the page, body isn't stored
+// because unchanged pages aren't parsed again
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Links        []string  `json:"links,omitempty"`
+	StoredAt     time.Time `json:"stored_at"`
+}
+
+// responseCache on-disk cache of responses keyed by canonical url
+type responseCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// newResponseCache creates cache in dir, entries expire after ttl, never if zero
+func newResponseCache(dir string, ttl time.Duration) (*responseCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	return &responseCache{
+		dir: dir,
+		ttl: ttl,
+	}, nil
+}
+
+// get returns not expired entry of url, nil if not found
+func (rc *responseCache) get(url string) (*cacheEntry, error) {
+	data, err := os.ReadFile(rc.path(url))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cache entry: %w", err)
+	}
+
+	if entry.URL != url || (rc.ttl > 0 && time.Since(entry.StoredAt) > rc.ttl) {
+		return nil, nil
+	}
+
+	return &entry, nil
+}
+
+// put stores entry, file is replaced atomically
+func (rc *responseCache) put(entry *cacheEntry) error {
+	path := rc.path(entry.URL)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to close cache entry: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to rename cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// path returns entry file path, files are spread over subdirs by hash prefix
+func (rc *responseCache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	name := hex.EncodeToString(sum[:])
+
+	return filepath.Join(rc.dir, name[:2], name+".json")
+}
+
+// cacheKeys returns canonical urls the page is cached under: requested url,
+// so page is found by the next request of the same link, and url after redirects
+func (s *Scraper) cacheKeys(r *colly.Request) []string {
+	keys := []string{s.canon.canonicalize(r.URL).String()}
+
+	if u, err := url.Parse(requestedURL(r)); err == nil {
+		if requested := s.canon.canonicalize(u).String(); requested != keys[0] {
+			keys = append([]string{requested}, keys...)
+		}
+	}
+
+	return keys
+}
+
+// setConditionalHeaders adds validators of cached page to request
+func (s *Scraper) setConditionalHeaders(r *colly.Request) error {
+	entry, err := s.cache.get(s.cacheKeys(r)[0])
+	if err != nil || entry == nil {
+		return err
+	}
+
+	if entry.ETag != "" {
+		r.Headers.Set("If-None-Match", entry.ETag)
+	}
+
+	if entry.LastModified != "" {
+		r.Headers.Set("If-Modified-Since", entry.LastModified)
+	}
+
+	return nil
+}
+
+// followCachedLinks follows links of unchanged page from cache
+func (s *Scraper) followCachedLinks(r *colly.Request) error {
+	entry, err := s.cache.get(s.cacheKeys(r)[0])
+	if err != nil || entry == nil {
+		return err
+	}
+
+	for _, link := range entry.Links {
+		if s.isCanceled() {
+			return nil
+		}
+
+		s.followLink(link, r.Depth+1)
+	}
+
+	return nil
+}
+
+// cacheLink saves page link to request context, so it's stored in cache with page
+func (s *Scraper) cacheLink(r *colly.Request, link string) {
+	if s.cache == nil {
+		return
+	}
+
+	links, _ := r.Ctx.GetAny(linksCtxKey).([]string)
+	r.Ctx.Put(linksCtxKey, append(links, link))
+}
+
+// storeResponse stores validators and links of the page, pages without validators
+// can't be requested conditionally, so they aren't stored
+func (s *Scraper) storeResponse(r *colly.Response) error {
+	if r.Headers == nil {
+		return nil
+	}
+
+	etag, lastModified := r.Headers.Get("ETag"), r.Headers.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return nil
+	}
+
+	links, _ := r.Ctx.GetAny(linksCtxKey).([]string)
+
+	for _, key := range s.cacheKeys(r.Request) {
+		err := s.cache.put(&cacheEntry{
+			URL:          key,
+			ETag:         etag,
+			LastModified: lastModified,
+			Links:        links,
+			StoredAt:     time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCacheFollowsRedirectedPage(t *testing.T) {
+	var conditional atomic.Int32
+
+	srv, log := newTestServer(t, func(w http.ResponseWriter, r *http.Request, _ int) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		case "/new":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				conditional.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.Header().Set("ETag", `"v1"`)
+			fmt.Fprint(w, `<html><body><a href="/page">page</a></body></html>`)
+		case "/page":
+			fmt.Fprint(w, `<html><body>page</body></html>`)
+		}
+	})
+
+	cacheDir := t.TempDir()
+
+	// the second run requests page conditionally and follows its cached links
+	for range 2 {
+		s := newTestScraper(t, func(cfg *Config) {
+			cfg.CacheDir = cacheDir
+		})
+
+		if err := s.VisitContext(context.Background(), srv.URL+"/old"); err != nil {
+			t.Fatalf("failed to visit: %v", err)
+		}
+	}
+
+	want := []string{"/old", "/new", "/page", "/old", "/new", "/page"}
+	if got := log.get(); !slices.Equal(got, want) || conditional.Load() != 1 {
+		t.Fatalf("got requests %v with %d conditional, want %v with 1 conditional", got, conditional.Load(), want)
+	}
+}
@@ -0,0 +1,165 @@
+package scraper
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+)
+
+// subdomain policies define which hosts belong to the site
+const (
+	// SubdomainsExact allows only exact site host
+	SubdomainsExact = "exact"
+	// SubdomainsWWW allows site host with or without www prefix
+	SubdomainsWWW = "www"
+	// SubdomainsAll allows site host and all its subdomains
+	SubdomainsAll = "all"
+)
+
+// defaultPorts ports which are omitted in canonical urls
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// canonicalizer normalizes urls and checks that they belong to the site
+type canonicalizer struct {
+	cfg     CanonicalConfig
+	domains []string
+}
+
+// newCanonicalizer creates canonicalizer with allowed domains
+func newCanonicalizer(cfg CanonicalConfig, allowedDomains []string) (*canonicalizer, error) {
+	switch cfg.Subdomains {
+	case "":
+		cfg.Subdomains = SubdomainsWWW
+	case SubdomainsExact, SubdomainsWWW, SubdomainsAll:
+	default:
+		return nil, fmt.Errorf("unknown subdomain policy: %s", cfg.Subdomains)
+	}
+
+	domains := make([]string, 0, len(allowedDomains))
+	for _, d := range allowedDomains {
+		domains = append(domains, normalizeHost(d))
+	}
+
+	return &canonicalizer{
+		cfg:     cfg,
+		domains: domains,
+	}, nil
+}
+
+// canonicalize returns canonical form of url: lowercase scheme and host, no default port,
+// no fragment, filtered and sorted query, cleaned path without trailing slash
+func (cn *canonicalizer) canonicalize(u *url.URL) *url.URL {
+	c := *u
+
+	c.Scheme = strings.ToLower(c.Scheme)
+	c.Host = strings.ToLower(c.Host)
+	c.Fragment = ""
+	c.RawFragment = ""
+	c.User = nil
+
+	if port := c.Port(); port != "" && defaultPorts[c.Scheme] == port {
+		c.Host = c.Hostname()
+		if strings.Contains(c.Host, ":") {
+			c.Host = "[" + c.Host + "]"
+		}
+	}
+
+	p := c.Path
+	if p == "" {
+		p = "/"
+	}
+
+	p = path.Clean(p)
+	if cn.cfg.KeepTrailingSlash && strings.HasSuffix(c.Path, "/") && p != "/" {
+		p += "/"
+	}
+
+	if cn.cfg.LowercasePath {
+		p = strings.ToLower(p)
+	}
+
+	c.Path = p
+	c.RawPath = ""
+
+	query := c.Query()
+	for k := range query {
+		if !cn.keepParam(k) {
+			query.Del(k)
+		}
+	}
+
+	// Encode sorts params by key
+	c.RawQuery = query.Encode()
+	c.ForceQuery = false
+
+	return &c
+}
+
+// keepParam checks query param against allow and deny lists
+func (cn *canonicalizer) keepParam(name string) bool {
+	name = strings.ToLower(name)
+
+	if len(cn.cfg.QueryAllow) > 0 && !slices.ContainsFunc(cn.cfg.QueryAllow, matchParam(name)) {
+		return false
+	}
+
+	return !slices.ContainsFunc(cn.cfg.QueryDeny, matchParam(name))
+}
+
+// matchParam returns matcher of param name against pattern, pattern may end with * for prefix match
+func matchParam(name string) func(pattern string) bool {
+	return func(pattern string) bool {
+		pattern = strings.ToLower(pattern)
+
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			return strings.HasPrefix(name, prefix)
+		}
+
+		return name == pattern
+	}
+}
+
+// isAllowedHost checks whether host belongs to site domain or allowed domains
+func (cn *canonicalizer) isAllowedHost(host, siteDomain string) bool {
+	host = normalizeHost(host)
+
+	if siteDomain != "" && cn.matchDomain(host, normalizeHost(siteDomain)) {
+		return true
+	}
+
+	for _, d := range cn.domains {
+		if cn.matchDomain(host, d) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchDomain checks host against domain with subdomain policy
+func (cn *canonicalizer) matchDomain(host, domain string) bool {
+	switch cn.cfg.Subdomains {
+	case SubdomainsExact:
+		return host == domain
+	case SubdomainsAll:
+		domain = strings.TrimPrefix(domain, "www.")
+		return host == domain || strings.HasSuffix(host, "."+domain)
+	default:
+		return strings.TrimPrefix(host, "www.") == strings.TrimPrefix(domain, "www.")
+	}
+}
+
+// normalizeHost lowercases host and strips port and trailing dot
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
@@ -5,14 +5,13 @@ import (
 )
 
 const (
-	defaultOutputEvery       = 1000
-	defaultMaxDepth          = 10
-	defaultFilterPattern     = "^[A-Za-zА-Яа-яЁё]+$"
-	defaultAsyncDelay        = 5 * time.Second
-	defaultAsyncRequestLimit = 5
+	defaultOutputEvery   = 1000
+	defaultMaxDepth      = 10
+	defaultFilterPattern = "^[A-Za-zА-Яа-яЁё]+$"
 )
 
 var (
+	defaultQueryDeny = []string{"utm_*", "fbclid", "gclid", "yclid"}
 	defaultTags      = []string{"div", "span", "p", "a", "h1", "h2", "h3", "h4", "h5", "h6"}
 	defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"
 	defaultHeaders   = map[string]string{
@@ -23,20 +22,127 @@ var (
 	}
 )
 
+// PolitenessConfig contains per-host rate limits, applied in any mode
+type PolitenessConfig struct {
+	// HostConcurrency specifies maximum number of concurrent requests to the host
+	HostConcurrency int `mapstructure:"host_concurrency"`
+	// Delay specifies minimum delay between requests to the host
+	Delay time.Duration `mapstructure:"delay"`
+	// MaxDelay specifies maximum delay when host responds with 429 or 503
+	MaxDelay time.Duration `mapstructure:"max_delay"`
+	// BackoffFactor specifies how delay is multiplied on 429 and 503 and divided on success
+	BackoffFactor float64 `mapstructure:"backoff_factor"`
+}
+
+// RetryConfig contains retry policy for failed page fetches
+type RetryConfig struct {
+	// MaxAttempts specifies maximum number of attempts including the first one
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// BaseDelay specifies delay before the first retry, it's doubled on each next one
+	BaseDelay time.Duration `mapstructure:"base_delay"`
+	// MaxDelay specifies maximum delay between attempts
+	MaxDelay time.Duration `mapstructure:"max_delay"`
+	// TransientStatuses specifies retried statuses, others are permanent failures.
+	// Network errors are always retried.
+	TransientStatuses []int `mapstructure:"transient_statuses"`
+}
+
+// ProxyConfig contains proxy rotation settings
+type ProxyConfig struct {
+	// Rotation specifies how proxies are switched: round_robin or random
+	Rotation string `mapstructure:"rotation"`
+	// MaxFailures specifies number of consecutive failures after which proxy is disabled
+	MaxFailures int `mapstructure:"max_failures"`
+	// Cooldown specifies how long failing proxy is disabled
+	Cooldown time.Duration `mapstructure:"cooldown"`
+}
+
 // QueueConfig contains configuration for scraper queue
 type QueueConfig struct {
 	// Enabled shows is queue enabled
 	Enabled bool `mapstructure:"enabled"`
 	// ThreadNumber specifies number of threads for queue processing
 	ThreadNumber int `mapstructure:"thread_number"`
-	// MaxSize specifies maximum size of in memory queue storage
+	// MaxSize specifies maximum size of queue storage
 	MaxSize int `mapstructure:"max_size"`
+	// Dir specifies directory where queue and visited set are persisted, so interrupted
+	// crawl is resumed by the next run of the site. Queue is kept in memory if empty.
+	Dir string `mapstructure:"dir"`
+	// ResumeMaxAge specifies how long after its start interrupted crawl can be resumed,
+	// older crawl is started again, unlimited if zero
+	ResumeMaxAge time.Duration `mapstructure:"resume_max_age"`
+}
+
+// SitemapConfig contains configuration for sitemap discovery
+type SitemapConfig struct {
+	// Enabled shows whether urls from sitemaps are used as crawl seeds
+	Enabled bool `mapstructure:"enabled"`
+	// MaxURLs specifies maximum number of urls taken from sitemaps
+	MaxURLs int `mapstructure:"max_urls"`
+	// MaxDepth specifies maximum nesting of sitemap indexes, sitemaps listed in discovered ones
+	// have depth 1
+	MaxDepth int `mapstructure:"max_depth"`
+	// MaxAge skips urls with lastmod older than it, unlimited if zero
+	MaxAge time.Duration `mapstructure:"max_age"`
+}
+
+// CanonicalConfig contains configuration for url canonicalization and host matching
+type CanonicalConfig struct {
+	// Subdomains specifies which hosts belong to the site: exact, www or all
+	Subdomains string `mapstructure:"subdomains"`
+	// QueryAllow specifies query params which are kept, all params are kept if empty
+	QueryAllow []string `mapstructure:"query_allow"`
+	// QueryDeny specifies query params which are removed, param may end with * for prefix match
+	QueryDeny []string `mapstructure:"query_deny"`
+	// KeepTrailingSlash shows whether trailing slash is kept in path
+	KeepTrailingSlash bool `mapstructure:"keep_trailing_slash"`
+	// LowercasePath shows whether path is lowercased
+	LowercasePath bool `mapstructure:"lowercase_path"`
+}
+
+// BudgetConfig contains crawl limits per site, zero values mean unlimited
+type BudgetConfig struct {
+	// MaxPages specifies maximum number of fetched pages
+	MaxPages int `mapstructure:"max_pages"`
+	// MaxBytes specifies maximum total size of responses
+	MaxBytes int64 `mapstructure:"max_bytes"`
+	// MaxWords specifies maximum number of emitted words
+	MaxWords int64 `mapstructure:"max_words"`
+	// MaxDuration specifies maximum duration of scraping
+	MaxDuration time.Duration `mapstructure:"max_duration"`
+}
+
+// WordsConfig contains settings of emitted words, words are compared in normalized form:
+// NFC composed, lowercase, with ё replaced by е
+type WordsConfig struct {
+	// Stopwords specifies languages of built-in stopword lists: ru, en
+	Stopwords []string `mapstructure:"stopwords"`
+	// CustomStopwords specifies additional words which are dropped
+	CustomStopwords []string `mapstructure:"custom_stopwords"`
+	// MinLength specifies minimum word length in characters
+	MinLength int `mapstructure:"min_length"`
+	// MaxLength specifies maximum word length in characters, 0 means unlimited
+	MaxLength int `mapstructure:"max_length"`
+	// DropCommonAfter specifies number of parsed pages after which words found on every page
+	// of the site are dropped, 0 disables it
+	DropCommonAfter int `mapstructure:"drop_common_after"`
+	// Stem shows whether words are replaced by their snowball stems
+	Stem bool `mapstructure:"stem"`
+	// Language specifies stemming language: ru, en or auto to detect it by script of every word
+	Language string `mapstructure:"language"`
+	// KeepOriginal shows whether original words are emitted alongside stems
+	KeepOriginal bool `mapstructure:"keep_original"`
 }
 
 // Config contains scraper setting
 type Config struct {
 	// OutputEvery specifies how often to output results
 	OutputEvery int `mapstructure:"output_every"`
+	// OutputMode specifies what is emitted: words or documents
+	OutputMode string `mapstructure:"output_mode"`
+	// PassageSize specifies maximum length of document passages in characters,
+	// passages keep sentences intact, 0 disables passages
+	PassageSize int `mapstructure:"passage_size"`
 	// LogErrors specifies whether to log errors
 	LogErrors bool `mapstructure:"log_errors"`
 	// MaxDepth specifies the maximum depth to crawl
@@ -45,43 +151,79 @@ type Config struct {
 	FilterPattern string `mapstructure:"filter_pattern"`
 	// TagsToParse specifies the HTML tags to parse
 	TagsToParse []string `mapstructure:"tags_to_parse"`
+	// ExtractionMode specifies how text is extracted: tags or readability
+	ExtractionMode string `mapstructure:"extraction_mode"`
+	// Words config for filtering of emitted words
+	Words WordsConfig `mapstructure:"words"`
 
-	// IsAsync shows is scraper should work in async mode
+	// IsAsync shows is scraper should work in async mode, with queue enabled
+	// requests are run concurrently by queue threads
 	IsAsync bool `mapstructure:"is_async"`
-	// AsyncDelay shows delay between async requests
+	// AsyncDelay is deprecated, used as Politeness.Delay if it isn't set
 	AsyncDelay time.Duration `mapstructure:"async_delay"`
-	// AsyncRequestLimit specifies the maximum number of concurrent requests
+	// AsyncRequestLimit is deprecated, used as Politeness.HostConcurrency if it isn't set
 	AsyncRequestLimit int `mapstructure:"async_request_limit"`
+	// Politeness config for per-host rate limits
+	Politeness PolitenessConfig `mapstructure:"politeness"`
 
 	// Headers specifies the headers to include in requests
 	Headers map[string]string `mapstructure:"headers"`
 	// AllowedDomains allowed domains for scraping
 	AllowedDomains []string `mapstructure:"allowed_domains"`
-	// CacheDir specifies directory for cache
+	// CacheDir specifies directory for response cache, cache is disabled if empty
 	CacheDir string `mapstructure:"cache_dir"`
-	// CacheExpiration specifies the duration for cache expiration
+	// CacheExpiration specifies the duration for cache expiration, entries never expire if zero
 	CacheExpiration time.Duration `mapstructure:"cache_expiration"`
-	// ProxyURLs defines list of proxy urls
+	// ProxyURLs defines list of http, https or socks5 proxy urls, credentials are set in url
 	ProxyURLs []string `mapstructure:"proxy_urls"`
+	// Proxy config for proxy rotation
+	Proxy ProxyConfig `mapstructure:"proxy"`
 	// UserAgent specifies the user agent for requests
 	UserAgent string `mapstructure:"user_agent"`
 
+	// RespectRobotsTxt shows whether robots.txt rules and crawl-delay are honored
+	RespectRobotsTxt bool `mapstructure:"respect_robots_txt"`
+	// RobotsUserAgent specifies user agent for robots.txt rules, UserAgent is used if empty
+	RobotsUserAgent string `mapstructure:"robots_user_agent"`
+	// RobotsCacheTTL specifies how long fetched robots.txt is cached
+	RobotsCacheTTL time.Duration `mapstructure:"robots_cache_ttl"`
+
 	// Queue config for scraper queue
 	Queue QueueConfig `mapstructure:"queue"`
+	// IncludePatterns only links matching any of them are followed if not empty,
+	// patterns are regexps or globs with "glob:" prefix matched against canonical url
+	IncludePatterns []string `mapstructure:"include_patterns"`
+	// ExcludePatterns links matching any of them aren't followed
+	ExcludePatterns []string `mapstructure:"exclude_patterns"`
+
+	// Retry config for failed page fetches
+	Retry RetryConfig `mapstructure:"retry"`
+	// Budget config for crawl limits
+	Budget BudgetConfig `mapstructure:"budget"`
+	// Canonical config for url canonicalization
+	Canonical CanonicalConfig `mapstructure:"canonical"`
+	// Sitemap config for sitemap discovery
+	Sitemap SitemapConfig `mapstructure:"sitemap"`
 }
 
 // DefaultConfig returns new config with default values
 func DefaultConfig() *Config {
 	cfg := Config{
-		IsAsync:           true,
-		AsyncDelay:        defaultAsyncDelay,
-		AsyncRequestLimit: defaultAsyncRequestLimit,
-		OutputEvery:       defaultOutputEvery,
-		MaxDepth:          defaultMaxDepth,
-		FilterPattern:     defaultFilterPattern,
-		TagsToParse:       defaultTags,
-		Headers:           defaultHeaders,
-		UserAgent:         defaultUserAgent,
+		IsAsync:       true,
+		OutputEvery:   defaultOutputEvery,
+		MaxDepth:      defaultMaxDepth,
+		FilterPattern: defaultFilterPattern,
+		TagsToParse:   defaultTags,
+		Headers:       defaultHeaders,
+		UserAgent:     defaultUserAgent,
+		Politeness: PolitenessConfig{
+			HostConcurrency: defaultHostConcurrency,
+			Delay:           defaultHostDelay,
+		},
+		Canonical: CanonicalConfig{
+			Subdomains: SubdomainsWWW,
+			QueryDeny:  defaultQueryDeny,
+		},
 	}
 
 	return &cfg
@@ -0,0 +1,202 @@
+package scraper
+
+import (
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// extraction modes
+const (
+	// ExtractionTags extracts text from all configured tags
+	ExtractionTags = "tags"
+	// ExtractionReadability extracts text from configured tags of main content only,
+	// falls back to tags mode if main content isn't found
+	ExtractionReadability = "readability"
+)
+
+// main content scoring settings
+const (
+	minParagraphLength  = 25
+	minContentScore     = 20.0
+	classWeight         = 25.0
+	semanticTagBonus    = 30.0
+	maxParagraphLenHits = 3
+)
+
+var (
+	// boilerplateTags are never part of main content
+	boilerplateTags = map[string]struct{}{
+		"nav": {}, "header": {}, "footer": {}, "aside": {}, "form": {},
+		"script": {}, "style": {}, "noscript": {}, "iframe": {}, "button": {},
+	}
+	// boilerplateRe matches class and id of navigation, banners and similar blocks
+	boilerplateRe = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|consent|disqus|footer|gdpr|header|legal|login|menu|modal|nav|pager|popup|promo|related|share|sidebar|signup|social|sponsor|subscribe|tags|toolbar|widget`)
+	// contentRe matches class and id of content blocks
+	contentRe = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|story|text|blog`)
+)
+
+// paragraphSelector elements whose text is scored
+const paragraphSelector = "p, pre, td, blockquote, li"
+
+// mainContent finds main content element of the document by text and link density,
+// semantic tags and class names, returns nil if nothing looks like main content
+func mainContent(doc *goquery.Selection) *goquery.Selection {
+	scores := make(map[*html.Node]float64)
+
+	doc.Find(paragraphSelector).Each(func(_ int, p *goquery.Selection) {
+		if isBoilerplate(p, nil) {
+			return
+		}
+
+		text := strings.TrimSpace(p.Text())
+		if len(text) < minParagraphLength {
+			return
+		}
+
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text)/100), maxParagraphLenHits)
+
+		// parent gets full score, grandparent gets half
+		parent := p.Parent()
+		if parent.Length() == 0 {
+			return
+		}
+		addScore(scores, parent, score)
+
+		if grandparent := parent.Parent(); grandparent.Length() > 0 {
+			addScore(scores, grandparent, score/2)
+		}
+	})
+
+	var (
+		best      *html.Node
+		bestScore float64
+	)
+
+	// candidates are compared in document order, so the first one wins a tie
+	for _, node := range slices.Concat(doc.Nodes, doc.Find("*").Nodes) {
+		score, ok := scores[node]
+		if !ok {
+			continue
+		}
+
+		sel := goquery.NewDocumentFromNode(node).Selection
+		score *= 1 - linkDensity(sel)
+
+		if score > bestScore {
+			best, bestScore = node, score
+		}
+	}
+
+	if best == nil || bestScore < minContentScore {
+		return nil
+	}
+
+	return doc.FindNodes(best)
+}
+
+// addScore adds paragraph score to element, initial score depends on tag and class
+func addScore(scores map[*html.Node]float64, sel *goquery.Selection, score float64) {
+	node := sel.Get(0)
+
+	if _, ok := scores[node]; !ok {
+		scores[node] = initialScore(sel)
+	}
+
+	scores[node] += score
+}
+
+// initialScore scores element by semantic tag and class names
+func initialScore(sel *goquery.Selection) float64 {
+	var score float64
+
+	switch goquery.NodeName(sel) {
+	case "article", "main":
+		score += semanticTagBonus
+	case "div":
+		score += 5
+	case "section", "td", "blockquote":
+		score += 3
+	}
+
+	if role, _ := sel.Attr("role"); role == "main" {
+		score += semanticTagBonus
+	}
+
+	names := sel.AttrOr("class", "") + " " + sel.AttrOr("id", "")
+	if contentRe.MatchString(names) {
+		score += classWeight
+	}
+	if boilerplateRe.MatchString(names) {
+		score -= classWeight
+	}
+
+	return score
+}
+
+// linkDensity returns share of element text inside links
+func linkDensity(sel *goquery.Selection) float64 {
+	textLen := len(strings.TrimSpace(sel.Text()))
+	if textLen == 0 {
+		return 0
+	}
+
+	var linkLen int
+	sel.Find("a").Each(func(_ int, a *goquery.Selection) {
+		linkLen += len(strings.TrimSpace(a.Text()))
+	})
+
+	return math.Min(1, float64(linkLen)/float64(textLen))
+}
+
+// isBoilerplate checks whether element or its ancestors up to root are navigation,
+// banners and similar blocks, ancestors up to body are checked if root is nil
+func isBoilerplate(sel *goquery.Selection, root *html.Node) bool {
+	for n := sel.Get(0); n != nil && n != root; n = n.Parent {
+		if n.Type != html.ElementNode {
+			continue
+		}
+
+		// page-wide classes of body say nothing about its blocks
+		if n.Data == "body" {
+			return false
+		}
+
+		if _, ok := boilerplateTags[n.Data]; ok {
+			return true
+		}
+
+		for _, attr := range n.Attr {
+			// main content may be marked with role main inside boilerplate-like wrapper
+			if attr.Key == "role" && attr.Val == "main" {
+				return false
+			}
+
+			if (attr.Key == "class" || attr.Key == "id") && boilerplateRe.MatchString(attr.Val) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// mainContentElements returns configured tags of main content without boilerplate blocks,
+// all configured tags of the document are returned if main content isn't found
+func (s *Scraper) mainContentElements(doc *goquery.Selection) *goquery.Selection {
+	content := mainContent(doc)
+	if content == nil {
+		return doc.Find(s.tags)
+	}
+
+	root := content.Get(0)
+
+	return content.Filter(s.tags).AddSelection(content.Find(s.tags)).
+		FilterFunction(func(_ int, sel *goquery.Selection) bool {
+			return !isBoilerplate(sel, root)
+		})
+}
@@ -0,0 +1,68 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// paragraph long enough to be scored
+const testParagraph = "<p>Go is a statically typed, compiled language, designed at Google, with memory safety.</p>"
+
+func TestMainContent(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		// want is id of main content element, empty if it isn't found
+		want string
+	}{
+		{
+			name: "article",
+			html: `<nav id="nav">` + strings.Repeat(testParagraph, 3) + `</nav>` +
+				`<div id="article">` + strings.Repeat(testParagraph, 3) + `</div>`,
+			want: "article",
+		},
+		{
+			name: "semantic tag wins",
+			html: `<div id="other">` + strings.Repeat(testParagraph, 3) + `</div>` +
+				`<article id="main">` + strings.Repeat(testParagraph, 3) + `</article>`,
+			want: "main",
+		},
+		{
+			name: "tie is won by the first element",
+			html: `<section><div id="first">` + strings.Repeat(testParagraph, 5) + `</div></section>` +
+				`<section><div id="second">` + strings.Repeat(testParagraph, 5) + `</div></section>`,
+			want: "first",
+		},
+		{
+			name: "short paragraphs",
+			html: `<div id="article"><p>too short</p><p>also short</p></div>`,
+		},
+		{
+			name: "links only",
+			html: `<div id="links">` + strings.Repeat(`<p><a href="/">`+testParagraph+`</a></p>`, 3) + `</div>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// result mustn't depend on map iteration order
+			for range 20 {
+				doc, err := goquery.NewDocumentFromReader(strings.NewReader("<html><body>" + tt.html + "</body></html>"))
+				if err != nil {
+					t.Fatalf("failed to parse html: %v", err)
+				}
+
+				var got string
+				if content := mainContent(doc.Find("html")); content != nil {
+					got, _ = content.Attr("id")
+				}
+
+				if got != tt.want {
+					t.Fatalf("got main content %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package scraper
+
+import (
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+	"github.com/keenywheels/go-spy/internal/pkg/nlp"
+)
+
+// output modes
+const (
+	// OutputWords emits filtered words of all pages joined by space every OutputEvery words
+	OutputWords = "words"
+	// OutputDocuments emits one document per page
+	OutputDocuments = "documents"
+)
+
+// urlCtxKey request context key with requested url before redirects
+const urlCtxKey = "url"
+
+// Document represents scraped page
+type Document struct {
+	// URL is requested url
+	URL string
+	// FinalURL is url after redirects
+	FinalURL string
+	// Status is response status code
+	Status int
+	// Title is page title
+	Title string
+	// Headings are texts of h1-h6 headings in document order
+	Headings []string
+	// MetaDescription is content of description meta tag
+	MetaDescription string
+	// Language is page language from html lang attribute or Content-Language
+	Language string
+	// FetchedAt is time when page was fetched
+	FetchedAt time.Time
+	// Text is extracted text of the page
+	Text string
+	// Passages are consecutive sentences of the text, each is at most PassageSize characters long
+	Passages []string
+}
+
+// documentCallback is a callback function that processes page documents
+type documentCallback func(Document)
+
+// SetDocumentCallback sets callback function for documents output mode
+func (s *Scraper) SetDocumentCallback(cb documentCallback) {
+	s.docCb = cb
+}
+
+// requestedURL returns url of request before redirects
+func requestedURL(r *colly.Request) string {
+	if requested, _ := r.Ctx.GetAny(urlCtxKey).(string); requested != "" {
+		return requested
+	}
+
+	return r.URL.String()
+}
+
+// buildDocument builds document of scraped page
+func (s *Scraper) buildDocument(e *colly.HTMLElement) Document {
+	doc := e.DOM
+
+	requested := requestedURL(e.Request)
+
+	headings := make([]string, 0)
+	doc.Find("h1, h2, h3, h4, h5, h6").Each(func(_ int, h *goquery.Selection) {
+		if text := normalizeSpace(h.Text()); text != "" {
+			headings = append(headings, text)
+		}
+	})
+
+	description, _ := doc.Find(`meta[name="description"]`).First().Attr("content")
+	texts := s.pageTexts(doc)
+
+	return Document{
+		URL:             requested,
+		FinalURL:        e.Request.URL.String(),
+		Status:          e.Response.StatusCode,
+		Title:           normalizeSpace(doc.Find("title").First().Text()),
+		Headings:        headings,
+		MetaDescription: normalizeSpace(description),
+		Language:        pageLanguage(e),
+		FetchedAt:       time.Now().UTC(),
+		Text:            strings.Join(texts, " "),
+		Passages:        s.passages(texts),
+	}
+}
+
+// passages splits texts into passages, texts of different elements are never joined into one sentence
+func (s *Scraper) passages(texts []string) []string {
+	if s.passageSize <= 0 {
+		return nil
+	}
+
+	sentences := make([]string, 0, len(texts))
+	for _, text := range texts {
+		sentences = append(sentences, nlp.Sentences(text)...)
+	}
+
+	return nlp.Passages(sentences, s.passageSize)
+}
+
+// emitDocument sends document to callback, document is dropped if words budget is exceeded
+func (s *Scraper) emitDocument(doc Document) {
+	words := len(s.filterText(doc.Text))
+	if words > 0 && s.budget.takeWords(words) == 0 {
+		return
+	}
+
+	if s.docCb != nil {
+		s.docCb(doc)
+	}
+}
+
+// pageTexts returns texts of configured tags according to extraction mode
+func (s *Scraper) pageTexts(doc *goquery.Selection) []string {
+	var elems *goquery.Selection
+
+	if s.extractionMode == ExtractionReadability {
+		elems = s.mainContentElements(doc)
+	} else {
+		elems = doc.Find(s.tags)
+	}
+
+	texts := make([]string, 0, elems.Length())
+	elems.Each(func(_ int, sel *goquery.Selection) {
+		if text := normalizeSpace(s.getDirectText(sel)); text != "" {
+			texts = append(texts, text)
+		}
+	})
+
+	return texts
+}
+
+// pageLanguage returns page language from html lang attribute, meta tag or response header
+func pageLanguage(e *colly.HTMLElement) string {
+	if lang := strings.TrimSpace(e.DOM.AttrOr("lang", "")); lang != "" {
+		return lang
+	}
+
+	var lang string
+	e.DOM.Find("meta[http-equiv]").EachWithBreak(func(_ int, meta *goquery.Selection) bool {
+		if strings.EqualFold(meta.AttrOr("http-equiv", ""), "content-language") {
+			lang = strings.TrimSpace(meta.AttrOr("content", ""))
+		}
+
+		return lang == ""
+	})
+
+	if lang != "" {
+		return lang
+	}
+
+	if e.Response.Headers != nil {
+		return strings.TrimSpace(e.Response.Headers.Get("Content-Language"))
+	}
+
+	return ""
+}
+
+// normalizeSpace collapses whitespace sequences into single spaces
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
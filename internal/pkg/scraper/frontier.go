@@ -0,0 +1,403 @@
+package scraper
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// frontier file names
+const (
+	frontierQueueFile   = "queue.jsonl"
+	frontierCursorFile  = "cursor.json"
+	frontierVisitedFile = "visited.txt"
+	frontierRunFile     = "run.json"
+)
+
+// frontier persisted crawl state of the site run: request queue and visited set.
+// Run directory is removed when crawl is finished and kept when it's interrupted by shutdown,
+// so the next run of the site resumes it.
+type frontier struct {
+	dir     string
+	run     frontierRun
+	storage *diskQueueStorage
+	visited *os.File
+}
+
+// frontierRun describes crawl persisted in run directory
+type frontierRun struct {
+	// RunID is id of the run which owns the crawl
+	RunID string `json:"run_id"`
+	// StartedAt is start time of the crawl, it's kept when crawl is resumed
+	StartedAt time.Time `json:"started_at"`
+}
+
+// openFrontier opens the latest unfinished crawl of the site or starts new one. Resumed crawl
+// is moved to the directory of runID, crawls started more than maxAge ago aren't resumed.
+func openFrontier(root, site, runID string, maxSize int, maxAge time.Duration) (*frontier, bool, error) {
+	siteDir := filepath.Join(root, sanitizePathPart(site))
+
+	if err := os.MkdirAll(siteDir, 0o755); err != nil {
+		return nil, false, fmt.Errorf("failed to create frontier dir: %w", err)
+	}
+
+	prevDir, prev, err := latestRun(siteDir, maxAge)
+	if err != nil {
+		return nil, false, err
+	}
+
+	dir := filepath.Join(siteDir, sanitizePathPart(runID))
+	run := frontierRun{RunID: runID, StartedAt: time.Now().UTC()}
+	resumed := prevDir != ""
+
+	if resumed {
+		run.StartedAt = prev.StartedAt
+
+		if prevDir != dir {
+			if err := os.Rename(prevDir, dir); err != nil {
+				return nil, false, fmt.Errorf("failed to move resumed run dir: %w", err)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, false, fmt.Errorf("failed to create run dir: %w", err)
+	}
+
+	if err := writeJSONFile(filepath.Join(dir, frontierRunFile), run); err != nil {
+		return nil, false, fmt.Errorf("failed to write run file: %w", err)
+	}
+
+	visited, err := os.OpenFile(filepath.Join(dir, frontierVisitedFile), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open visited file: %w", err)
+	}
+
+	return &frontier{
+		dir:     dir,
+		run:     run,
+		storage: &diskQueueStorage{dir: dir, maxSize: maxSize},
+		visited: visited,
+	}, resumed, nil
+}
+
+// latestRun returns directory of the latest unfinished crawl in site dir, empty if there is no one.
+// Other crawls are removed along with ones which are too old or have no valid run file.
+func latestRun(siteDir string, maxAge time.Duration) (string, frontierRun, error) {
+	entries, err := os.ReadDir(siteDir)
+	if err != nil {
+		return "", frontierRun{}, fmt.Errorf("failed to read frontier dir: %w", err)
+	}
+
+	var (
+		latestDir string
+		latest    frontierRun
+		stale     []string
+	)
+
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		dir := filepath.Join(siteDir, e.Name())
+
+		var run frontierRun
+		if err := readJSONFile(filepath.Join(dir, frontierRunFile), &run); err != nil ||
+			(maxAge > 0 && time.Since(run.StartedAt) > maxAge) {
+			stale = append(stale, dir)
+			continue
+		}
+
+		if latestDir == "" || run.StartedAt.After(latest.StartedAt) {
+			if latestDir != "" {
+				stale = append(stale, latestDir)
+			}
+
+			latestDir, latest = dir, run
+			continue
+		}
+
+		stale = append(stale, dir)
+	}
+
+	for _, dir := range stale {
+		if err := os.RemoveAll(dir); err != nil {
+			return "", frontierRun{}, fmt.Errorf("failed to remove stale run dir: %w", err)
+		}
+	}
+
+	return latestDir, latest, nil
+}
+
+// loadVisited reads visited set, partially written last line is skipped
+func (f *frontier) loadVisited() (map[string]struct{}, error) {
+	if _, err := f.visited.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek visited file: %w", err)
+	}
+
+	visited := make(map[string]struct{})
+
+	r := bufio.NewReader(f.visited)
+	for {
+		line, err := r.ReadString('\n')
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read visited file: %w", err)
+		}
+
+		visited[strings.TrimSuffix(line, "\n")] = struct{}{}
+	}
+
+	return visited, nil
+}
+
+// markVisited appends url to visited set
+func (f *frontier) markVisited(link string) error {
+	if _, err := f.visited.WriteString(link + "\n"); err != nil {
+		return fmt.Errorf("failed to write visited url: %w", err)
+	}
+
+	return nil
+}
+
+// close closes frontier files
+func (f *frontier) close() error {
+	return errors.Join(f.storage.close(), f.visited.Close())
+}
+
+// remove closes frontier and removes run directory
+func (f *frontier) remove() error {
+	return errors.Join(f.close(), os.RemoveAll(f.dir))
+}
+
+// diskQueueStorage implements colly queue storage as append-only file of requests
+// with persisted read cursor. Taken requests aren't returned again after restart,
+// so requests in flight are lost on crash, canceled ones are stored back by scraper.
+type diskQueueStorage struct {
+	dir     string
+	maxSize int
+
+	mu     sync.Mutex
+	file   *os.File
+	reader *bufio.Reader
+	cursor int64
+	end    int64
+	size   int
+}
+
+// queueCursor persisted read position of queue file
+type queueCursor struct {
+	Offset int64 `json:"offset"`
+}
+
+// Init implements queue.Storage, opens queue file and restores cursor
+func (ds *diskQueueStorage) Init() error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	file, err := os.OpenFile(filepath.Join(ds.dir, frontierQueueFile), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open queue file: %w", err)
+	}
+
+	ds.file = file
+
+	if err := ds.load(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return nil
+}
+
+// load restores cursor, counts stored requests and truncates partially written one
+func (ds *diskQueueStorage) load() error {
+	data, err := os.ReadFile(filepath.Join(ds.dir, frontierCursorFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read cursor: %w", err)
+	}
+
+	var cursor queueCursor
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &cursor); err != nil {
+			return fmt.Errorf("failed to unmarshal cursor: %w", err)
+		}
+	}
+
+	content, err := io.ReadAll(ds.file)
+	if err != nil {
+		return fmt.Errorf("failed to read queue file: %w", err)
+	}
+
+	// drop partially written last request
+	end := int64(bytes.LastIndexByte(content, '\n') + 1)
+	if err := ds.file.Truncate(end); err != nil {
+		return fmt.Errorf("failed to truncate queue file: %w", err)
+	}
+
+	ds.end = end
+	ds.cursor = min(cursor.Offset, end)
+	ds.size = bytes.Count(content[ds.cursor:end], []byte{'\n'})
+
+	ds.resetReader()
+
+	return nil
+}
+
+// AddRequest implements queue.Storage
+func (ds *diskQueueStorage) AddRequest(r []byte) error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	if ds.maxSize > 0 && ds.size >= ds.maxSize {
+		return colly.ErrQueueFull
+	}
+
+	line := append(slices.Clip(r), '\n')
+
+	n, err := ds.file.WriteAt(line, ds.end)
+	if err != nil {
+		return fmt.Errorf("failed to write request: %w", err)
+	}
+
+	ds.end += int64(n)
+	ds.size++
+
+	return nil
+}
+
+// GetRequest implements queue.Storage, cursor is persisted after each request
+func (ds *diskQueueStorage) GetRequest() ([]byte, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	if ds.size == 0 {
+		return nil, errors.New("the queue is empty")
+	}
+
+	// state is changed only after it's persisted, so request isn't lost if cursor isn't saved
+	line, err := ds.reader.ReadBytes('\n')
+	if err != nil {
+		ds.resetReader()
+		return nil, fmt.Errorf("failed to read request: %w", err)
+	}
+
+	// queue is drained, so file is truncated to keep it small. Cursor is saved after
+	// truncation, because cursor past the end of file is reset on load.
+	if ds.size == 1 {
+		if err := ds.file.Truncate(0); err != nil {
+			ds.resetReader()
+			return nil, fmt.Errorf("failed to truncate queue file: %w", err)
+		}
+
+		ds.cursor, ds.end, ds.size = 0, 0, 0
+		ds.resetReader()
+
+		if err := ds.saveCursor(0); err != nil {
+			return nil, err
+		}
+
+		return bytes.TrimSuffix(line, []byte{'\n'}), nil
+	}
+
+	if err := ds.saveCursor(ds.cursor + int64(len(line))); err != nil {
+		ds.resetReader()
+		return nil, err
+	}
+
+	ds.cursor += int64(len(line))
+	ds.size--
+
+	return bytes.TrimSuffix(line, []byte{'\n'}), nil
+}
+
+// QueueSize implements queue.Storage
+func (ds *diskQueueStorage) QueueSize() (int, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	return ds.size, nil
+}
+
+// resetReader positions reader at cursor
+func (ds *diskQueueStorage) resetReader() {
+	ds.reader = bufio.NewReader(io.NewSectionReader(ds.file, ds.cursor, 1<<62))
+}
+
+// saveCursor persists cursor at offset
+func (ds *diskQueueStorage) saveCursor(offset int64) error {
+	if err := writeJSONFile(filepath.Join(ds.dir, frontierCursorFile), queueCursor{Offset: offset}); err != nil {
+		return fmt.Errorf("failed to write cursor: %w", err)
+	}
+
+	return nil
+}
+
+// close closes queue file
+func (ds *diskQueueStorage) close() error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	if ds.file == nil {
+		return nil
+	}
+
+	return ds.file.Close()
+}
+
+// sanitizePathPart makes string safe to use as single path element
+func sanitizePathPart(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', 0:
+			return '_'
+		}
+
+		return r
+	}, s)
+
+	if s == "" || s == "." || s == ".." {
+		return "_"
+	}
+
+	return s
+}
+
+// writeJSONFile writes value as json via temporary file, so file is never partially written
+func writeJSONFile(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// readJSONFile reads json file into value
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
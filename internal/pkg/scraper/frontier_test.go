@@ -0,0 +1,343 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+func openTestStorage(t *testing.T, dir string) *diskQueueStorage {
+	t.Helper()
+
+	ds := &diskQueueStorage{dir: dir}
+	if err := ds.Init(); err != nil {
+		t.Fatalf("failed to init storage: %v", err)
+	}
+
+	return ds
+}
+
+func addRequests(t *testing.T, ds *diskQueueStorage, from, to int) {
+	t.Helper()
+
+	for i := from; i < to; i++ {
+		if err := ds.AddRequest([]byte(strconv.Itoa(i))); err != nil {
+			t.Fatalf("failed to add request: %v", err)
+		}
+	}
+}
+
+func getRequests(t *testing.T, ds *diskQueueStorage, from, to int) {
+	t.Helper()
+
+	for i := from; i < to; i++ {
+		data, err := ds.GetRequest()
+		if err != nil {
+			t.Fatalf("failed to get request: %v", err)
+		}
+
+		if string(data) != strconv.Itoa(i) {
+			t.Fatalf("got request %q, want %d", data, i)
+		}
+	}
+}
+
+func checkQueueSize(t *testing.T, ds *diskQueueStorage, want int) {
+	t.Helper()
+
+	size, err := ds.QueueSize()
+	if err != nil {
+		t.Fatalf("failed to get queue size: %v", err)
+	}
+
+	if size != want {
+		t.Fatalf("queue size is %d, want %d", size, want)
+	}
+}
+
+func TestDiskQueueStorageRestart(t *testing.T) {
+	tests := []struct {
+		name  string
+		added int
+		taken int
+	}{
+		{name: "nothing taken", added: 5, taken: 0},
+		{name: "partially taken", added: 5, taken: 2},
+		{name: "drained", added: 5, taken: 5},
+		{name: "empty", added: 0, taken: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			ds := openTestStorage(t, dir)
+			addRequests(t, ds, 0, tt.added)
+			getRequests(t, ds, 0, tt.taken)
+
+			if err := ds.close(); err != nil {
+				t.Fatalf("failed to close storage: %v", err)
+			}
+
+			// taken requests aren't returned after restart
+			ds = openTestStorage(t, dir)
+			defer ds.close()
+
+			checkQueueSize(t, ds, tt.added-tt.taken)
+			getRequests(t, ds, tt.taken, tt.added)
+
+			// requests added after restart follow the restored ones
+			addRequests(t, ds, tt.added, tt.added+2)
+			getRequests(t, ds, tt.added, tt.added+2)
+			checkQueueSize(t, ds, 0)
+		})
+	}
+}
+
+func TestDiskQueueStorageDropsPartialRequest(t *testing.T) {
+	dir := t.TempDir()
+
+	ds := openTestStorage(t, dir)
+	addRequests(t, ds, 0, 2)
+
+	// simulate crash in the middle of write
+	if _, err := ds.file.WriteAt([]byte(`{"url":"ht`), ds.end); err != nil {
+		t.Fatalf("failed to write partial request: %v", err)
+	}
+
+	ds.close()
+
+	ds = openTestStorage(t, dir)
+	defer ds.close()
+
+	checkQueueSize(t, ds, 2)
+	addRequests(t, ds, 2, 3)
+	getRequests(t, ds, 0, 3)
+}
+
+func TestDiskQueueStorageMaxSize(t *testing.T) {
+	ds := &diskQueueStorage{dir: t.TempDir(), maxSize: 2}
+	if err := ds.Init(); err != nil {
+		t.Fatalf("failed to init storage: %v", err)
+	}
+	defer ds.close()
+
+	addRequests(t, ds, 0, 2)
+
+	if err := ds.AddRequest([]byte("2")); err == nil {
+		t.Fatal("expected queue full error")
+	}
+}
+
+func TestOpenFrontier(t *testing.T) {
+	root := t.TempDir()
+
+	f, resumed, err := openFrontier(root, "site", "run1", 0, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to open frontier: %v", err)
+	}
+
+	if resumed {
+		t.Fatal("new frontier is resumed")
+	}
+
+	if err := f.storage.Init(); err != nil {
+		t.Fatalf("failed to init storage: %v", err)
+	}
+
+	addRequests(t, f.storage, 0, 3)
+	getRequests(t, f.storage, 0, 1)
+
+	if err := f.markVisited("https://example.com/"); err != nil {
+		t.Fatalf("failed to mark visited: %v", err)
+	}
+
+	started := f.run.StartedAt
+	f.close()
+
+	// the next run resumes crawl under its own id
+	f, resumed, err = openFrontier(root, "site", "run2", 0, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to open frontier: %v", err)
+	}
+
+	if !resumed || f.run.RunID != "run2" || !f.run.StartedAt.Equal(started) {
+		t.Fatalf("resumed=%t run=%+v, want resumed run2 started at %s", resumed, f.run, started)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "site", "run1")); !os.IsNotExist(err) {
+		t.Fatalf("old run dir isn't moved: %v", err)
+	}
+
+	visited, err := f.loadVisited()
+	if err != nil {
+		t.Fatalf("failed to load visited: %v", err)
+	}
+
+	if _, ok := visited["https://example.com/"]; !ok || len(visited) != 1 {
+		t.Fatalf("unexpected visited set: %v", visited)
+	}
+
+	if err := f.storage.Init(); err != nil {
+		t.Fatalf("failed to init storage: %v", err)
+	}
+
+	checkQueueSize(t, f.storage, 2)
+	getRequests(t, f.storage, 1, 3)
+
+	// finished crawl is removed
+	if err := f.remove(); err != nil {
+		t.Fatalf("failed to remove frontier: %v", err)
+	}
+
+	f, resumed, err = openFrontier(root, "site", "run3", 0, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to open frontier: %v", err)
+	}
+	defer f.close()
+
+	if resumed {
+		t.Fatal("removed frontier is resumed")
+	}
+}
+
+func TestOpenFrontierSkipsStaleRuns(t *testing.T) {
+	root := t.TempDir()
+	siteDir := filepath.Join(root, "site")
+
+	runs := map[string]time.Duration{
+		"old":    -3 * time.Hour,
+		"recent": -30 * time.Minute,
+		"older":  -50 * time.Minute,
+	}
+
+	for id, age := range runs {
+		dir := filepath.Join(siteDir, id)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("failed to create run dir: %v", err)
+		}
+
+		run := frontierRun{RunID: id, StartedAt: time.Now().Add(age)}
+		if err := writeJSONFile(filepath.Join(dir, frontierRunFile), run); err != nil {
+			t.Fatalf("failed to write run file: %v", err)
+		}
+	}
+
+	// run dir without run file is left by crash before it was written
+	if err := os.MkdirAll(filepath.Join(siteDir, "broken"), 0o755); err != nil {
+		t.Fatalf("failed to create run dir: %v", err)
+	}
+
+	f, resumed, err := openFrontier(root, "site", "new", 0, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to open frontier: %v", err)
+	}
+	defer f.close()
+
+	if !resumed || !f.run.StartedAt.Before(time.Now().Add(-29*time.Minute)) ||
+		!f.run.StartedAt.After(time.Now().Add(-31*time.Minute)) {
+		t.Fatalf("resumed=%t run=%+v, want resumed recent run", resumed, f.run)
+	}
+
+	entries, err := os.ReadDir(siteDir)
+	if err != nil {
+		t.Fatalf("failed to read site dir: %v", err)
+	}
+
+	if len(entries) != 1 || entries[0].Name() != "new" {
+		t.Fatalf("unexpected run dirs left: %v", entries)
+	}
+}
+
+func TestCanceledCrawlKeepsOnlyInterruptedRequests(t *testing.T) {
+	srv, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request, _ int) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body><a href="/slow">slow</a><a href="/fast">fast</a></body></html>`)
+		case "/slow":
+			// request is in flight until crawl is canceled
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		case "/fast":
+			fmt.Fprint(w, `<html><body><a href="/next">next</a></body></html>`)
+		default:
+			fmt.Fprint(w, `<html><body>page</body></html>`)
+		}
+	})
+
+	root := t.TempDir()
+	s := newTestScraper(t, func(cfg *Config) {
+		cfg.Queue = QueueConfig{Enabled: true, ThreadNumber: 2, Dir: root}
+		cfg.Politeness = PolitenessConfig{HostConcurrency: 2}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// crawl is canceled after fast page is fetched, but before it's parsed
+	s.c.OnResponse(func(r *colly.Response) {
+		if r.Request.URL.Path == "/fast" {
+			cancel()
+		}
+	})
+
+	var cancelErr *CancelError
+	if err := s.VisitWithSiteNameContext(ctx, srv.URL+"/", "site"); !errors.As(err, &cancelErr) {
+		t.Fatalf("got error %v, want cancel error", err)
+	}
+
+	f, resumed, err := openFrontier(root, "site", "next", 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open frontier: %v", err)
+	}
+	defer f.close()
+
+	if err := f.storage.Init(); err != nil {
+		t.Fatalf("failed to init storage: %v", err)
+	}
+
+	var paths []string
+	for {
+		size, err := f.storage.QueueSize()
+		if err != nil {
+			t.Fatalf("failed to get queue size: %v", err)
+		}
+
+		if size == 0 {
+			break
+		}
+
+		data, err := f.storage.GetRequest()
+		if err != nil {
+			t.Fatalf("failed to get request: %v", err)
+		}
+
+		var req struct {
+			URL string
+		}
+		if err := json.Unmarshal(data, &req); err != nil {
+			t.Fatalf("failed to unmarshal request: %v", err)
+		}
+
+		paths = append(paths, strings.TrimPrefix(req.URL, srv.URL))
+	}
+
+	// aborted request is repeated, fetched one isn't, but its links are kept
+	slices.Sort(paths)
+	if !resumed || !slices.Equal(paths, []string{"/next", "/slow"}) {
+		t.Fatalf("resumed=%t queue=%v, want resumed queue with /next and /slow", resumed, paths)
+	}
+}
@@ -0,0 +1,80 @@
+package scraper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/keenywheels/go-spy/pkg/logger/zap"
+)
+
+// requestLog records paths requested from test server
+type requestLog struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+// add records path and returns how many times it's requested
+func (l *requestLog) add(path string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.paths = append(l.paths, path)
+
+	n := 0
+	for _, p := range l.paths {
+		if p == path {
+			n++
+		}
+	}
+
+	return n
+}
+
+// get returns recorded paths
+func (l *requestLog) get() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return slices.Clone(l.paths)
+}
+
+// newTestServer starts server which records requested paths, handler gets number of path requests
+func newTestServer(t *testing.T, h func(w http.ResponseWriter, r *http.Request, n int)) (*httptest.Server, *requestLog) {
+	t.Helper()
+
+	log := &requestLog{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h(w, r, log.add(r.URL.Path))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, log
+}
+
+// newTestScraper creates sync scraper which requests one page at a time, configure changes default config
+func newTestScraper(t *testing.T, configure func(cfg *Config)) *Scraper {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	cfg.IsAsync = false
+	cfg.Politeness = PolitenessConfig{HostConcurrency: 1}
+
+	if configure != nil {
+		configure(cfg)
+	}
+
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatalf("failed to create scraper: %v", err)
+	}
+
+	s.SetOutputCallback(func(_, _ string) {})
+	s.Init(zap.New(zap.LogPath(filepath.Join(t.TempDir(), "app.log"))))
+
+	return s
+}
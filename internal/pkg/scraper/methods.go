@@ -1,12 +1,20 @@
 package scraper
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"net/http"
 	"net/url"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly/v2"
+	"github.com/keenywheels/go-spy/internal/pkg/nlp"
 	"github.com/keenywheels/go-spy/pkg/logger"
 )
 
@@ -15,55 +23,174 @@ func (s *Scraper) SetOutputCallback(cb outputCallback) {
 	s.cb = cb
 }
 
+// SetRobotsCache sets robots.txt cache, so it can be shared between scrapers.
+// Does nothing if robots.txt isn't respected.
+func (s *Scraper) SetRobotsCache(rc *RobotsCache) {
+	if s.robots != nil && rc != nil {
+		s.robots = rc
+	}
+}
+
+// FilterStats returns number of links dropped by each exclude rule and rejected by each include rule
+func (s *Scraper) FilterStats() []FilterStat {
+	return s.urlFilter.stats()
+}
+
 // Init initializes scraper
 func (s *Scraper) Init(l logger.Logger) {
+	s.logger = l
+
 	// set headers
 	s.c.OnRequest(func(r *colly.Request) {
+		// scraping is canceled, request is dropped
+		if s.isCanceled() {
+			interrupt(r)
+			return
+		}
+
+		// check robots.txt rules and set crawl delay
+		if s.robots != nil {
+			allowed, err := s.checkRobots(r.URL)
+			if err != nil && s.isLogErrors {
+				l.Warnf("[Scraper]: failed to get robots.txt: URL=%s, Error=%v", r.URL, err)
+			}
+
+			if s.isCanceled() {
+				interrupt(r)
+				return
+			}
+
+			if !allowed {
+				r.Abort()
+				return
+			}
+		}
+
 		for k, v := range s.headers {
 			r.Headers.Set(k, v)
 		}
+
+		// remember requested url, request url is changed by redirects
+		if r.Ctx.GetAny(urlCtxKey) == nil {
+			r.Ctx.Put(urlCtxKey, r.URL.String())
+		}
+
+		// request cached page conditionally
+		if s.cache != nil {
+			if err := s.setConditionalHeaders(r); err != nil && s.isLogErrors {
+				l.Warnf("[Scraper]: failed to get cached page: URL=%s, Error=%v", r.URL, err)
+			}
+		}
+
+		// retried page is already counted
+		if requestAttempt(r) == 0 && !s.budget.reservePage() {
+			r.Abort()
+		}
 	})
 
-	// parse tags for text
-	s.c.OnHTML(s.tags, func(e *colly.HTMLElement) {
-		txt := s.getDirectText(e)
-		s.saveWords(s.filterText(txt))
+	// count fetched bytes
+	s.c.OnResponse(func(r *colly.Response) {
+		s.budget.addBytes(len(r.Body))
 	})
 
+	// check canonical url before parsing, so duplicates are skipped
+	s.c.OnHTML(`link[rel="canonical"]`, func(e *colly.HTMLElement) {
+		s.checkCanonical(e)
+	})
+
+	// count pages for common words, it's registered before text handlers, so it's called first
+	if s.outputMode != OutputDocuments {
+		s.c.OnHTML("html", func(e *colly.HTMLElement) {
+			if !isDuplicate(e.Request) {
+				s.words.addPage()
+			}
+		})
+	}
+
+	// parse tags for text
+	switch {
+	case s.outputMode == OutputDocuments:
+		s.c.OnHTML("html", func(e *colly.HTMLElement) {
+			if isDuplicate(e.Request) {
+				return
+			}
+
+			s.emitDocument(s.buildDocument(e))
+		})
+	case s.extractionMode == ExtractionReadability:
+		s.c.OnHTML("html", func(e *colly.HTMLElement) {
+			if isDuplicate(e.Request) {
+				return
+			}
+
+			s.mainContentElements(e.DOM).Each(func(_ int, sel *goquery.Selection) {
+				s.saveWords(s.words.dropCommon(e.Request.ID, s.filterText(s.getDirectText(sel))))
+			})
+		})
+	default:
+		s.c.OnHTML(s.tags, func(e *colly.HTMLElement) {
+			if isDuplicate(e.Request) {
+				return
+			}
+
+			txt := s.getDirectText(e.DOM)
+			s.saveWords(s.words.dropCommon(e.Request.ID, s.filterText(txt)))
+		})
+	}
+
 	// parse links
 	s.c.OnHTML("a[href]", func(e *colly.HTMLElement) {
+		// don't follow new links when scraping is canceled, unless they're stored in frontier
+		// for resumed crawl, fetched page isn't repeated, so its links would be lost
+		if (s.isCanceled() && s.frontier == nil) || isDuplicate(e.Request) {
+			return
+		}
+
 		link := e.Request.AbsoluteURL(e.Attr("href"))
 
-		// filter links
-		if s.filterLink(link) {
-			s.mu.Lock()
-			s.visited[link] = struct{}{}
-			s.mu.Unlock()
-
-			// add to queue if exists
-			if s.q != nil {
-				req, err := getCollyRequest(link, e.Request.Depth+1)
-				if err != nil {
-					l.Errorf("[scraper]: failed to create colly request: %v", err)
-					return
-				}
-
-				s.q.AddRequest(req)
-				return
+		s.cacheLink(e.Request, link)
+		s.followLink(link, e.Request.Depth+1)
+	})
+
+	// store validators and links of parsed page
+	if s.cache != nil {
+		s.c.OnScraped(func(r *colly.Response) {
+			if err := s.storeResponse(r); err != nil && s.isLogErrors {
+				l.Warnf("[Scraper]: failed to cache page: URL=%s, Error=%v", r.Request.URL, err)
 			}
+		})
+	}
 
-			// no queue -> call visit directly
-			s.c.Visit(link)
+	// retry transient failures
+	s.c.OnError(func(r *colly.Response, err error) {
+		if s.isCanceled() {
+			return
 		}
+
+		// page isn't changed, so text isn't emitted again, but its links are followed
+		if r.StatusCode == http.StatusNotModified {
+			if err := s.followCachedLinks(r.Request); err != nil && s.isLogErrors {
+				l.Warnf("[Scraper]: failed to get cached page: URL=%s, Error=%v", r.Request.URL, err)
+			}
+			return
+		}
+
+		attempt := requestAttempt(r.Request) + 1
+		delay, ok := s.retries.next(r)
+
+		if s.isLogErrors {
+			l.Errorf("[Scraper]: got scraper error: URL=%s, Error=%v, Status=%d, Attempt=%d, Retry=%t\n",
+				r.Request.URL, err, r.StatusCode, attempt, ok)
+		}
+
+		if !ok {
+			return
+		}
+
+		s.scheduleRetry(r.Request, delay)
 	})
 
 	if s.isLogErrors {
-		// logging errors
-		s.c.OnError(func(r *colly.Response, err error) {
-			l.Errorf("[Scraper]: got scraper error: URL=%s, Error=%v, Status=%d\n",
-				r.Request.URL, err, r.StatusCode)
-		})
-
 		// logging not ok responses
 		s.c.OnResponse(func(r *colly.Response) {
 			if r.StatusCode < 200 && r.StatusCode >= 300 {
@@ -77,38 +204,303 @@ func (s *Scraper) Init(l logger.Logger) {
 
 // Visit start scraping from specified url
 func (s *Scraper) Visit(url string) error {
-	s.prepareScraper("", url)
+	return s.VisitContext(context.Background(), url)
+}
+
+// VisitWithSiteName start scraping from specified url
+func (s *Scraper) VisitWithSiteName(url string, siteName string) error {
+	return s.VisitWithSiteNameContext(context.Background(), url, siteName)
+}
+
+// VisitContext start scraping from specified url until context is done
+func (s *Scraper) VisitContext(ctx context.Context, url string) error {
+	return s.visit(ctx, "", url)
+}
+
+// VisitWithSiteNameContext start scraping from specified url until context is done.
+// When context is done, new links aren't followed, in-flight requests are aborted,
+// collected words are flushed and *CancelError is returned.
+// When crawl budget is exceeded, scraping is stopped the same way, but in-flight
+// requests are finished and *BudgetExceededError is returned.
+func (s *Scraper) VisitWithSiteNameContext(ctx context.Context, url string, siteName string) error {
+	return s.visit(ctx, siteName, url)
+}
+
+// SetRunID sets id of the run, used as frontier key along with site name
+func (s *Scraper) SetRunID(runID string) {
+	s.runID = runID
+}
+
+// visit performs scraping from specified url, frontier is resumed if it's persisted
+func (s *Scraper) visit(ctx context.Context, siteName, siteURL string) error {
+	s.prepareScraper(siteName, siteURL)
+
+	resumed, err := s.openFrontier(siteName)
+	if err != nil {
+		return fmt.Errorf("failed to open frontier: %w", err)
+	}
+
+	err = s.crawl(ctx, siteURL, resumed)
+	s.closeFrontier(err)
+
+	return err
+}
+
+// crawl performs scraping from specified url, root url isn't queued for resumed crawl
+func (s *Scraper) crawl(ctx context.Context, siteURL string, resumed bool) error {
+	// scraping is stopped when context is done or budget is exceeded,
+	// in-flight requests are aborted only when context is done
+	stopCtx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+
+	release := s.budget.start(stop)
+	defer release()
+
+	s.ctx = stopCtx
+	s.c.Context = ctx
+
+	// flush words collected before cancellation
+	defer s.Flush()
+
+	var err error
 
 	// using queue if exists
-	if s.q != nil {
-		req, err := getCollyRequest(url, 0)
+	if s.qs != nil {
+		stopQueue := context.AfterFunc(stopCtx, s.qs.stop)
+		defer stopQueue()
+
+		req, reqErr := getCollyRequest(siteURL, 0)
+		if reqErr != nil {
+			return fmt.Errorf("failed to create colly request: %w", reqErr)
+		}
+
+		if !resumed {
+			s.enqueue(req)
+		}
+		s.addSitemapSeeds(stopCtx, req.URL)
+
+		err = s.runQueue()
+	} else {
+		err = s.c.Visit(siteURL)
+		// colly reports statuses as text errors, unchanged cached root page isn't an error
+		if err != nil && err.Error() == http.StatusText(http.StatusNotModified) {
+			err = nil
+		}
+
+		if parsedURL, parseErr := url.Parse(siteURL); parseErr == nil {
+			s.addSitemapSeeds(stopCtx, parsedURL)
+		}
+	}
+
+	s.waitRetries()
+
+	if ctx.Err() != nil {
+		return &CancelError{Err: context.Cause(ctx)}
+	}
+
+	var budgetErr *BudgetExceededError
+	if errors.As(context.Cause(stopCtx), &budgetErr) {
+		return budgetErr
+	}
+
+	return err
+}
+
+// openFrontier opens persisted frontier of the site if queue dir is set,
+// returns true if unfinished crawl is resumed
+func (s *Scraper) openFrontier(siteName string) (bool, error) {
+	if s.qs == nil || s.frontierDir == "" {
+		return false, nil
+	}
+
+	site := siteName
+	if site == "" {
+		site = s.siteDomain
+	}
+
+	runID := s.runID
+	if runID == "" {
+		runID = time.Now().UTC().Format("20060102T150405Z")
+	}
+
+	f, resumed, err := openFrontier(s.frontierDir, site, runID, s.queueMaxSize, s.resumeMaxAge)
+	if err != nil {
+		return false, err
+	}
+
+	if err := f.storage.Init(); err != nil {
+		f.close()
+		return false, fmt.Errorf("failed to init queue storage: %w", err)
+	}
+
+	if resumed {
+		visited, err := f.loadVisited()
 		if err != nil {
-			return fmt.Errorf("failed to create colly request: %w", err)
+			f.close()
+			return false, err
 		}
 
-		s.q.AddRequest(req)
-		s.q.Run(s.c)
+		s.mu.Lock()
+		maps.Copy(s.visited, visited)
+		s.mu.Unlock()
+
+		if s.logger != nil {
+			s.logger.Infof("[Scraper]: resuming crawl of site %s started at %s", site, f.run.StartedAt)
+		}
 	}
 
-	return s.c.Visit(url)
+	s.frontier = f
+	s.qs.Storage = f.storage
+	s.qs.stopped.Store(false)
+
+	return resumed, nil
 }
 
-// VisitWithSiteName start scraping from specified url
-func (s *Scraper) VisitWithSiteName(url string, siteName string) error {
-	s.prepareScraper(siteName, url)
+// closeFrontier keeps frontier of crawl interrupted by shutdown for resume and removes others,
+// so crawl stopped by deadline is started again instead of being resumed and stopped forever
+func (s *Scraper) closeFrontier(err error) {
+	if s.frontier == nil {
+		return
+	}
 
-	// using queue if exists
-	if s.q != nil {
-		req, err := getCollyRequest(url, 0)
+	var (
+		cancelErr *CancelError
+		closeErr  error
+	)
+
+	if errors.As(err, &cancelErr) && errors.Is(cancelErr.Err, context.Canceled) {
+		closeErr = s.frontier.close()
+	} else {
+		closeErr = s.frontier.remove()
+	}
+
+	if closeErr != nil && s.logger != nil {
+		s.logger.Errorf("[Scraper]: failed to close frontier: %v", closeErr)
+	}
+
+	s.frontier = nil
+}
+
+// enqueue adds request to queue storage, request is dropped if queue is full
+func (s *Scraper) enqueue(req *colly.Request) {
+	data, err := req.Marshal()
+	if err != nil {
+		return
+	}
+
+	s.qs.AddRequest(data)
+}
+
+// runQueue processes queued requests by queue threads until queue is empty or stopped.
+// Running requests may add new ones, so queue is checked again after all of them are finished.
+func (s *Scraper) runQueue() error {
+	var (
+		wg      sync.WaitGroup
+		threads = make(chan struct{}, s.threads)
+	)
+
+	for {
+		size, err := s.qs.QueueSize()
+		if err != nil {
+			wg.Wait()
+			return fmt.Errorf("failed to get queue size: %w", err)
+		}
+
+		if size == 0 {
+			wg.Wait()
+			// delayed retries are added to queue when their delay is over
+			s.waitRetries()
+
+			if size, err = s.qs.QueueSize(); err != nil || size == 0 {
+				return err
+			}
+
+			continue
+		}
+
+		data, err := s.qs.GetRequest()
+		if err != nil {
+			wg.Wait()
+			return fmt.Errorf("failed to get queued request: %w", err)
+		}
+
+		req, err := s.c.UnmarshalRequest(slices.Clone(data))
+		if err != nil {
+			wg.Wait()
+			return fmt.Errorf("failed to unmarshal queued request: %w", err)
+		}
+
+		threads <- struct{}{}
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-threads }()
+
+			// retried request is already marked as visited by collector
+			var err error
+			if requestAttempt(req) > 0 {
+				err = req.Retry()
+			} else {
+				err = req.Do()
+			}
+
+			// request interrupted by cancellation is stored back, so resumed crawl repeats it,
+			// finished requests aren't repeated
+			if s.frontier != nil && isInterrupted(req, err) {
+				req.Ctx.Put(interruptedCtxKey, false)
+				s.enqueue(req)
+			}
+		}()
+	}
+}
+
+// addSitemapSeeds adds urls from site sitemaps with root depth
+func (s *Scraper) addSitemapSeeds(ctx context.Context, root *url.URL) {
+	if !s.sitemap.Enabled {
+		return
+	}
+
+	urls, err := s.sitemapURLs(ctx, root)
+	if err != nil {
+		if s.isLogErrors && s.logger != nil {
+			s.logger.Warnf("[Scraper]: failed to get sitemap urls: URL=%s, Error=%v", root, err)
+		}
+		return
+	}
+
+	for _, link := range urls {
+		if s.isCanceled() {
+			return
+		}
+
+		s.followLink(link, 0)
+	}
+}
+
+// followLink visits link or adds it to queue, if it belongs to the site and isn't visited yet
+func (s *Scraper) followLink(link string, depth int) {
+	link, ok := s.filterLink(link)
+	if !ok {
+		return
+	}
+
+	// add to queue if exists
+	if s.qs != nil {
+		req, err := getCollyRequest(link, depth)
 		if err != nil {
-			return fmt.Errorf("failed to create colly request: %w", err)
+			if s.logger != nil {
+				s.logger.Errorf("[scraper]: failed to create colly request: %v", err)
+			}
+			return
 		}
 
-		s.q.AddRequest(req)
-		s.q.Run(s.c)
+		s.enqueue(req)
+		return
 	}
 
-	return s.c.Visit(url)
+	// no queue -> call visit directly
+	s.c.Visit(link)
 }
 
 // Flush flushes remaining output
@@ -117,29 +509,39 @@ func (s *Scraper) Flush() {
 	defer s.mu.Unlock()
 
 	if len(s.output) > 0 {
-		msg := strings.Join(s.output, " ")
-		s.cb(msg)
-		s.output = s.output[:0]
+		s.emitOutput()
 	}
 }
 
+// emitOutput sends collected words to callback and resets output, must be called with mu locked
+func (s *Scraper) emitOutput() {
+	s.cb(strings.Join(s.output, " "), strings.Join(s.original, " "))
+	s.output = s.output[:0]
+	s.original = s.original[:0]
+}
+
+// isCanceled shows whether scraping context is done
+func (s *Scraper) isCanceled() bool {
+	return s.ctx != nil && s.ctx.Err() != nil
+}
+
 // getDirectText get only direct text in element
-func (s *Scraper) getDirectText(e *colly.HTMLElement) string {
+func (s *Scraper) getDirectText(sel *goquery.Selection) string {
 	// check if leaf elem
 	isLeaf := true
-	e.DOM.Children().Each(func(i int, s *goquery.Selection) {
+	sel.Children().Each(func(i int, s *goquery.Selection) {
 		isLeaf = false
 	})
 
 	// leaf -> return text
 	if isLeaf {
-		return e.Text
+		return sel.Text()
 	}
 
 	// not leaf -> get only direct text in element
 	var txt strings.Builder
 
-	e.DOM.Contents().Each(func(i int, s *goquery.Selection) {
+	sel.Contents().Each(func(i int, s *goquery.Selection) {
 		if goquery.NodeName(s) == "#text" {
 			txt.WriteString(s.Text())
 		}
@@ -148,14 +550,14 @@ func (s *Scraper) getDirectText(e *colly.HTMLElement) string {
 	return txt.String()
 }
 
-// filterText filters text and returns only valid words
+// filterText filters text and returns only valid normalized words
 func (s *Scraper) filterText(txt string) []string {
 	parsedWords := make([]string, 0, 100)
 
 	words := strings.FieldsSeq(txt)
 	for w := range words {
-		w = strings.ToLower(w)
-		if !s.filter.MatchString(w) {
+		w = nlp.Normalize(w)
+		if !s.filter.MatchString(w) || !s.words.keep(w) {
 			continue
 		}
 
@@ -170,12 +572,14 @@ func (s *Scraper) saveWords(words []string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	words = words[:s.budget.takeWords(len(words))]
+	original := s.words.stem(words)
+
 	s.output = append(s.output, words...)
+	s.original = append(s.original, original...)
 
 	if len(s.output) >= s.outputEvery {
-		msg := strings.Join(s.output, " ")
-		s.cb(msg)
-		s.output = s.output[:0]
+		s.emitOutput()
 	}
 }
 
@@ -186,15 +590,43 @@ func (s *Scraper) prepareScraper(name, siteURL string) {
 	var domain string
 	parsedURL, err := url.Parse(siteURL)
 	if err == nil {
-		domain = parsedURL.Hostname()
+		domain = parsedURL.Host
+		s.visited[s.canon.canonicalize(parsedURL).String()] = struct{}{}
 	}
 
 	s.siteName = name
 	s.siteDomain = domain
 }
 
-// filterLink checks if link belongs to the same domain
-func (s *Scraper) filterLink(link string) bool {
+// filterLink checks if link belongs to the site, passes url rules and isn't visited yet,
+// marks its canonical form as visited and returns link without fragment.
+// Canonical url is used only for deduplication, because site may not serve it,
+// e.g. path without trailing slash.
+func (s *Scraper) filterLink(link string) (string, bool) {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", false
+	}
+
+	if !s.canon.isAllowedHost(u.Host, s.siteDomain) {
+		return "", false
+	}
+
+	canonical := s.canon.canonicalize(u).String()
+
+	// filtered links stay visited, so each link is counted by rules once
+	if !s.markVisited(canonical) || !s.urlFilter.allow(canonical) {
+		return "", false
+	}
+
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	return u.String(), true
+}
+
+// markVisited marks canonical url as visited, returns false if it's already visited
+func (s *Scraper) markVisited(link string) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -202,11 +634,51 @@ func (s *Scraper) filterLink(link string) bool {
 		return false
 	}
 
-	if strings.Contains(link, s.siteDomain) || strings.Contains(link, s.siteName) {
-		return true
+	s.visited[link] = struct{}{}
+
+	if s.frontier != nil {
+		if err := s.frontier.markVisited(link); err != nil && s.logger != nil {
+			s.logger.Errorf("[Scraper]: failed to persist visited url: %v", err)
+		}
 	}
 
-	return false
+	return true
+}
+
+// checkCanonical handles <link rel=canonical>: page is marked as duplicate
+// if its canonical url differs from the page url and is already visited
+func (s *Scraper) checkCanonical(e *colly.HTMLElement) {
+	u, err := url.Parse(e.Request.AbsoluteURL(e.Attr("href")))
+	if err != nil || u.Host == "" || !s.canon.isAllowedHost(u.Host, s.siteDomain) {
+		return
+	}
+
+	canonical := s.canon.canonicalize(u).String()
+	if canonical == s.canon.canonicalize(e.Request.URL).String() {
+		return
+	}
+
+	if !s.markVisited(canonical) {
+		e.Request.Ctx.Put(duplicateCtxKey, true)
+	}
+}
+
+// interrupt drops request because scraping is canceled
+func interrupt(r *colly.Request) {
+	r.Ctx.Put(interruptedCtxKey, true)
+	r.Abort()
+}
+
+// isInterrupted shows whether request is dropped or its fetch is aborted because scraping is canceled
+func isInterrupted(r *colly.Request, err error) bool {
+	interrupted, _ := r.Ctx.GetAny(interruptedCtxKey).(bool)
+	return interrupted || errors.Is(err, context.Canceled)
+}
+
+// isDuplicate shows whether page is a duplicate of already visited one
+func isDuplicate(r *colly.Request) bool {
+	duplicate, _ := r.Ctx.GetAny(duplicateCtxKey).(bool)
+	return duplicate
 }
 
 // getCollyRequest creates colly request from url
@@ -0,0 +1,117 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestVisitContextCancel(t *testing.T) {
+	tests := []struct {
+		name     string
+		queue    bool
+		deadline bool
+		want     error
+	}{
+		{name: "canceled", want: context.Canceled},
+		{name: "deadline", deadline: true, want: context.DeadlineExceeded},
+		{name: "canceled queue", queue: true, want: context.Canceled},
+		{name: "deadline queue", queue: true, deadline: true, want: context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if tt.deadline {
+				ctx, cancel = context.WithTimeout(ctx, 200*time.Millisecond)
+				defer cancel()
+			}
+
+			srv, log := newTestServer(t, func(w http.ResponseWriter, r *http.Request, _ int) {
+				switch r.URL.Path {
+				case "/":
+					fmt.Fprint(w, `<html><body><p>golang channels</p><a href="/slow">slow</a><a href="/next">next</a></body></html>`)
+				case "/slow":
+					if !tt.deadline {
+						cancel()
+					}
+
+					// request is in flight until crawl is canceled
+					select {
+					case <-r.Context().Done():
+					case <-time.After(5 * time.Second):
+					}
+				default:
+					fmt.Fprint(w, `<html><body><p>rust</p></body></html>`)
+				}
+			})
+
+			var (
+				mu     sync.Mutex
+				output []string
+			)
+
+			s := newTestScraper(t, func(cfg *Config) {
+				cfg.Queue = QueueConfig{Enabled: tt.queue, ThreadNumber: 1}
+			})
+			s.SetOutputCallback(func(msg, _ string) {
+				mu.Lock()
+				defer mu.Unlock()
+				output = append(output, msg)
+			})
+
+			start := time.Now()
+			err := s.VisitContext(ctx, srv.URL+"/")
+
+			// in-flight request is aborted
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Fatalf("crawl is stopped after %s", elapsed)
+			}
+
+			var cancelErr *CancelError
+			if !errors.As(err, &cancelErr) || !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want cancel error caused by %v", err, tt.want)
+			}
+
+			if paths := log.get(); slices.Contains(paths, "/next") {
+				t.Fatalf("link is followed after cancel: %v", paths)
+			}
+
+			// words collected before cancel are flushed
+			mu.Lock()
+			defer mu.Unlock()
+
+			if got := strings.Join(output, " "); !strings.Contains(got, "golang") {
+				t.Fatalf("got output %q, want words of root page", got)
+			}
+		})
+	}
+}
+
+func TestVisitContextCanceledBeforeStart(t *testing.T) {
+	srv, log := newTestServer(t, func(w http.ResponseWriter, _ *http.Request, _ int) {
+		fmt.Fprint(w, `<html><body>page</body></html>`)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := newTestScraper(t, nil)
+
+	var cancelErr *CancelError
+	if err := s.VisitContext(ctx, srv.URL+"/"); !errors.As(err, &cancelErr) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want cancel error", err)
+	}
+
+	if paths := log.get(); len(paths) != 0 {
+		t.Fatalf("pages are requested after cancel: %v", paths)
+	}
+}
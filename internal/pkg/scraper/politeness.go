@@ -0,0 +1,207 @@
+package scraper
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// default politeness settings
+const (
+	defaultHostConcurrency = 2
+	defaultHostDelay       = time.Second
+	defaultMaxHostDelay    = time.Minute
+	defaultBackoffFactor   = 2.0
+)
+
+// politenessConfig returns politeness config, deprecated async limits are used as fallback
+func politenessConfig(cfg *Config) PolitenessConfig {
+	pc := cfg.Politeness
+
+	if pc.HostConcurrency == 0 {
+		pc.HostConcurrency = cfg.AsyncRequestLimit
+	}
+
+	if pc.Delay == 0 {
+		pc.Delay = cfg.AsyncDelay
+	}
+
+	return pc
+}
+
+// politeTransport enforces per-host concurrency and delay between requests,
+// slows down on 429 and 503 responses and speeds up back on successful ones
+type politeTransport struct {
+	next http.RoundTripper
+	cfg  PolitenessConfig
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+// hostState politeness state of the host
+type hostState struct {
+	sem chan struct{}
+
+	mu         sync.Mutex
+	next       time.Time
+	delay      time.Duration
+	crawlDelay time.Duration
+}
+
+// newPoliteTransport wraps transport with politeness limits
+func newPoliteTransport(next http.RoundTripper, cfg PolitenessConfig) *politeTransport {
+	if cfg.HostConcurrency <= 0 {
+		cfg.HostConcurrency = defaultHostConcurrency
+	}
+
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = defaultMaxHostDelay
+	}
+
+	if cfg.BackoffFactor <= 1 {
+		cfg.BackoffFactor = defaultBackoffFactor
+	}
+
+	return &politeTransport{
+		next:  next,
+		cfg:   cfg,
+		hosts: make(map[string]*hostState),
+	}
+}
+
+// RoundTrip implements http.RoundTripper, host slot is held until response body is closed
+func (p *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	h := p.host(req.URL.Host)
+
+	select {
+	case h.sem <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	release := sync.OnceFunc(func() { <-h.sem })
+
+	if err := p.wait(req, h); err != nil {
+		release()
+		return nil, err
+	}
+
+	resp, err := p.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	p.adapt(h, resp)
+
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+
+	return resp, nil
+}
+
+// SetCrawlDelay sets minimum delay between requests to the host
+func (p *politeTransport) SetCrawlDelay(host string, delay time.Duration) {
+	h := p.host(host)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.crawlDelay = delay
+}
+
+// host returns state of the host, creates it if not exists
+func (p *politeTransport) host(host string) *hostState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h, ok := p.hosts[host]
+	if !ok {
+		h = &hostState{
+			sem:   make(chan struct{}, p.cfg.HostConcurrency),
+			delay: p.cfg.Delay,
+		}
+		p.hosts[host] = h
+	}
+
+	return h
+}
+
+// wait reserves next request slot of the host and waits for it
+func (p *politeTransport) wait(req *http.Request, h *hostState) error {
+	now := time.Now()
+
+	h.mu.Lock()
+	start := h.next
+	if start.Before(now) {
+		start = now
+	}
+	h.next = start.Add(max(h.delay, h.crawlDelay))
+	h.mu.Unlock()
+
+	wait := time.Until(start)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+// adapt increases host delay on 429 and 503 responses, respecting Retry-After,
+// and decreases it back to configured one on other responses
+func (p *politeTransport) adapt(h *hostState, resp *http.Response) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		h.delay = max(p.cfg.Delay, time.Duration(float64(h.delay)/p.cfg.BackoffFactor))
+		return
+	}
+
+	h.delay = min(p.cfg.MaxDelay, max(defaultHostDelay, time.Duration(float64(h.delay)*p.cfg.BackoffFactor)))
+
+	if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		if next := time.Now().Add(min(retryAfter, p.cfg.MaxDelay)); next.After(h.next) {
+			h.next = next
+		}
+	}
+}
+
+// releaseBody releases host slot when response body is closed
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+// Close closes body and releases host slot
+func (b *releaseBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+// parseRetryAfter parses Retry-After header in seconds or http date format
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return max(0, time.Until(t)), true
+	}
+
+	return 0, false
+}
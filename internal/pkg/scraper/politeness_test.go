@@ -0,0 +1,98 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestPoliteTransport creates transport which allows one request per host,
+// requests to /fail path fail in wrapped transport
+func newTestPoliteTransport() *politeTransport {
+	return newPoliteTransport(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path == "/fail" {
+			return nil, errors.New("connection refused")
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader("ok")),
+		}, nil
+	}), PolitenessConfig{HostConcurrency: 1})
+}
+
+func TestPoliteTransportHostSlot(t *testing.T) {
+	tests := []struct {
+		name        string
+		first       string
+		closeBody   bool
+		second      string
+		wantBlocked bool
+	}{
+		{name: "slot is held until body is closed", first: "http://a.test/", second: "http://a.test/", wantBlocked: true},
+		{name: "slot is released when body is closed", first: "http://a.test/", closeBody: true, second: "http://a.test/"},
+		{name: "slot is released on transport error", first: "http://a.test/fail", second: "http://a.test/"},
+		{name: "hosts have their own slots", first: "http://a.test/", second: "http://b.test/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPoliteTransport()
+
+			req, _ := http.NewRequest(http.MethodGet, tt.first, nil)
+
+			resp, err := p.RoundTrip(req)
+			if err == nil && tt.closeBody {
+				resp.Body.Close()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			req, _ = http.NewRequestWithContext(ctx, http.MethodGet, tt.second, nil)
+
+			resp, err = p.RoundTrip(req)
+			if blocked := errors.Is(err, context.DeadlineExceeded); blocked != tt.wantBlocked {
+				t.Fatalf("second request blocked=%t, want %t: %v", blocked, tt.wantBlocked, err)
+			}
+
+			if err == nil {
+				resp.Body.Close()
+			}
+		})
+	}
+}
+
+func TestPoliteTransportSlotIsReleasedOnce(t *testing.T) {
+	p := newTestPoliteTransport()
+
+	req, _ := http.NewRequest(http.MethodGet, "http://a.test/", nil)
+
+	first, err := p.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+
+	first.Body.Close()
+
+	second, err := p.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	defer second.Body.Close()
+
+	// repeated close doesn't free slot held by another request
+	first.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := p.RoundTrip(req.WithContext(ctx)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got error %v, want request blocked by held slot", err)
+	}
+}
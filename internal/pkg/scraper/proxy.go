@@ -0,0 +1,179 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// proxy rotation modes
+const (
+	// ProxyRoundRobin uses proxies in turn
+	ProxyRoundRobin = "round_robin"
+	// ProxyRandom uses random proxy for each request
+	ProxyRandom = "random"
+)
+
+// default proxy settings
+const (
+	defaultProxyMaxFailures = 3
+	defaultProxyCooldown    = 5 * time.Minute
+)
+
+// errNoHealthyProxy is returned when all proxies are disabled
+var errNoHealthyProxy = errors.New("no healthy proxy")
+
+// proxyCtxKey request context key of chosen proxy holder
+type proxyCtxKey struct{}
+
+// proxyState proxy with its health
+type proxyState struct {
+	url           *url.URL
+	failures      int
+	disabledUntil time.Time
+}
+
+// proxyPool rotates proxies and disables failing ones for cooldown, health is checked
+// passively: network errors and proxy error statuses are counted as failures
+type proxyPool struct {
+	next http.RoundTripper
+	cfg  ProxyConfig
+
+	mu      sync.Mutex
+	proxies []*proxyState
+	cursor  int
+}
+
+// newProxyPool creates proxy pool and sets it as proxy func of transport,
+// http, https and socks5 proxies with credentials in url are supported
+func newProxyPool(transport *http.Transport, urls []string, cfg ProxyConfig) (*proxyPool, error) {
+	switch cfg.Rotation {
+	case "":
+		cfg.Rotation = ProxyRoundRobin
+	case ProxyRoundRobin, ProxyRandom:
+	default:
+		return nil, fmt.Errorf("unknown proxy rotation: %s", cfg.Rotation)
+	}
+
+	if cfg.MaxFailures <= 0 {
+		cfg.MaxFailures = defaultProxyMaxFailures
+	}
+
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = defaultProxyCooldown
+	}
+
+	proxies := make([]*proxyState, 0, len(urls))
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy url: %w", err)
+		}
+
+		switch parsed.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme: %s", parsed.Scheme)
+		}
+
+		proxies = append(proxies, &proxyState{url: parsed})
+	}
+
+	p := proxyPool{
+		next:    transport,
+		cfg:     cfg,
+		proxies: proxies,
+	}
+
+	transport.Proxy = p.proxy
+
+	return &p, nil
+}
+
+// RoundTrip implements http.RoundTripper and reports proxy health
+func (p *proxyPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	var chosen *proxyState
+
+	req = req.WithContext(context.WithValue(req.Context(), proxyCtxKey{}, &chosen))
+
+	resp, err := p.next.RoundTrip(req)
+	if chosen == nil {
+		return resp, err
+	}
+
+	// canceled requests say nothing about proxy health
+	if err != nil && req.Context().Err() != nil {
+		return resp, err
+	}
+
+	switch {
+	case err != nil,
+		resp.StatusCode == http.StatusProxyAuthRequired,
+		resp.StatusCode == http.StatusBadGateway,
+		resp.StatusCode == http.StatusGatewayTimeout:
+		p.fail(chosen)
+	default:
+		p.succeed(chosen)
+	}
+
+	return resp, err
+}
+
+// proxy chooses healthy proxy for request, used as transport proxy func
+func (p *proxyPool) proxy(req *http.Request) (*url.URL, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+
+	healthy := make([]*proxyState, 0, len(p.proxies))
+	for _, ps := range p.proxies {
+		if !ps.disabledUntil.After(now) {
+			healthy = append(healthy, ps)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return nil, errNoHealthyProxy
+	}
+
+	var chosen *proxyState
+
+	if p.cfg.Rotation == ProxyRandom {
+		chosen = healthy[rand.IntN(len(healthy))]
+	} else {
+		chosen = healthy[p.cursor%len(healthy)]
+		p.cursor++
+	}
+
+	if holder, ok := req.Context().Value(proxyCtxKey{}).(**proxyState); ok {
+		*holder = chosen
+	}
+
+	return chosen.url, nil
+}
+
+// fail counts proxy failure and disables proxy after too many consecutive ones
+func (p *proxyPool) fail(ps *proxyState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ps.failures++
+	if ps.failures >= p.cfg.MaxFailures {
+		ps.failures = 0
+		ps.disabledUntil = time.Now().Add(p.cfg.Cooldown)
+	}
+}
+
+// succeed resets proxy failures
+func (p *proxyPool) succeed(ps *proxyState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ps.failures = 0
+}
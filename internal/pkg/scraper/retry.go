@@ -0,0 +1,200 @@
+package scraper
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// default retry settings
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBaseDelay   = time.Second
+	defaultRetryMaxDelay    = time.Minute
+)
+
+// attemptCtxKey request context key with number of failed attempts
+const attemptCtxKey = "attempt"
+
+// defaultTransientStatuses statuses which are retried by default
+var defaultTransientStatuses = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooEarly,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// retryPolicy decides whether failed request is retried and when
+type retryPolicy struct {
+	cfg RetryConfig
+}
+
+// newRetryPolicy creates retry policy, zero values are replaced with defaults
+func newRetryPolicy(cfg RetryConfig) *retryPolicy {
+	if cfg.MaxAttempts == 0 {
+		cfg.MaxAttempts = defaultRetryMaxAttempts
+	}
+
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = defaultRetryBaseDelay
+	}
+
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = defaultRetryMaxDelay
+	}
+
+	if cfg.TransientStatuses == nil {
+		cfg.TransientStatuses = defaultTransientStatuses
+	}
+
+	return &retryPolicy{cfg: cfg}
+}
+
+// isTransient shows whether failure may disappear on retry,
+// network errors have zero status and are always transient
+func (p *retryPolicy) isTransient(status int) bool {
+	return status == 0 || slices.Contains(p.cfg.TransientStatuses, status)
+}
+
+// next returns delay before next attempt, false if request mustn't be retried.
+// Delay grows exponentially with jitter, Retry-After is honored up to max delay.
+func (p *retryPolicy) next(r *colly.Response) (time.Duration, bool) {
+	attempt := requestAttempt(r.Request) + 1
+	if attempt >= p.cfg.MaxAttempts || !p.isTransient(r.StatusCode) {
+		return 0, false
+	}
+
+	r.Request.Ctx.Put(attemptCtxKey, attempt)
+
+	// delay stops doubling at max delay, so it doesn't overflow for large number of attempts
+	delay := p.cfg.BaseDelay
+	for i := 1; i < attempt && delay < p.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	delay = min(delay, p.cfg.MaxDelay)
+	// equal jitter keeps at least half of delay
+	delay = delay/2 + rand.N(delay/2+1)
+
+	if r.Headers != nil {
+		if retryAfter, ok := parseRetryAfter(r.Headers.Get("Retry-After")); ok {
+			delay = max(delay, min(retryAfter, p.cfg.MaxDelay))
+		}
+	}
+
+	return delay, true
+}
+
+// requestAttempt returns number of failed attempts of request,
+// attempt of request restored from queue is decoded as float64
+func requestAttempt(r *colly.Request) int {
+	switch attempt := r.Ctx.GetAny(attemptCtxKey).(type) {
+	case int:
+		return attempt
+	case float64:
+		return int(attempt)
+	}
+
+	return 0
+}
+
+// pendingRetries counts retries waiting for their delay
+type pendingRetries struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	n    int
+
+	// submit keeps retries from being submitted to collector while it's awaited,
+	// colly doesn't allow to add request to idle collector concurrently with Wait
+	submit sync.RWMutex
+}
+
+// newPendingRetries creates retries counter
+func newPendingRetries() *pendingRetries {
+	p := &pendingRetries{}
+	p.cond = sync.NewCond(&p.mu)
+
+	return p
+}
+
+// add changes number of pending retries, waiters are woken up when there are no more
+func (p *pendingRetries) add(delta int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.n += delta
+	if p.n == 0 {
+		p.cond.Broadcast()
+	}
+}
+
+// wait waits until there are no pending retries, returns false if there were none
+func (p *pendingRetries) wait() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	waited := p.n > 0
+	for p.n > 0 {
+		p.cond.Wait()
+	}
+
+	return waited
+}
+
+// scheduleRetry retries request after delay without holding collector thread.
+// Request is added to queue if it exists, otherwise it's retried by collector.
+// Retry is dropped when scraping is stopped, unless frontier is kept for resume.
+func (s *Scraper) scheduleRetry(r *colly.Request, delay time.Duration) {
+	ctx := s.ctx
+	s.pending.add(1)
+
+	go func() {
+		defer s.pending.add(-1)
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			// interrupted retry is stored back, so resumed crawl repeats it
+			if s.frontier != nil {
+				s.enqueue(r)
+			}
+			return
+		}
+
+		if s.qs != nil {
+			s.enqueue(r)
+			return
+		}
+
+		s.pending.submit.RLock()
+		err := r.Retry()
+		s.pending.submit.RUnlock()
+
+		if err != nil && s.isLogErrors && s.logger != nil {
+			s.logger.Errorf("[Scraper]: failed to retry request: URL=%s, Error=%v\n", r.URL, err)
+		}
+	}()
+}
+
+// waitRetries waits until collector requests, scheduled retries and requests started by them are finished
+func (s *Scraper) waitRetries() {
+	for {
+		s.pending.submit.Lock()
+		s.c.Wait()
+		s.pending.submit.Unlock()
+
+		if !s.pending.wait() {
+			return
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+func TestRetryDoesNotBlockCrawl(t *testing.T) {
+	tests := []struct {
+		name  string
+		async bool
+		queue bool
+	}{
+		{name: "sync"},
+		{name: "async", async: true},
+		{name: "queue", queue: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, log := newTestServer(t, func(w http.ResponseWriter, r *http.Request, n int) {
+				switch r.URL.Path {
+				case "/":
+					fmt.Fprint(w, `<html><body><a href="/fail">fail</a><a href="/ok">ok</a></body></html>`)
+				case "/fail":
+					// the first attempt fails
+					if n == 1 {
+						w.WriteHeader(http.StatusInternalServerError)
+						return
+					}
+
+					fmt.Fprint(w, `<html><body>recovered</body></html>`)
+				default:
+					fmt.Fprint(w, `<html><body>ok</body></html>`)
+				}
+			})
+
+			s := newTestScraper(t, func(cfg *Config) {
+				cfg.IsAsync = tt.async
+				cfg.Queue.Enabled = tt.queue
+				cfg.Retry = RetryConfig{MaxAttempts: 2, BaseDelay: 300 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+			})
+
+			if err := s.VisitContext(context.Background(), srv.URL+"/"); err != nil {
+				t.Fatalf("failed to visit: %v", err)
+			}
+
+			// crawl goes on while failed page waits for retry, crawl is finished after retry
+			requests := log.get()
+			if len(requests) != 4 || requests[len(requests)-1] != "/fail" ||
+				slices.Index(requests, "/ok") > 2 {
+				t.Fatalf("got requests %v, want /ok requested before /fail is retried", requests)
+			}
+		})
+	}
+}
+
+func TestRequestAttempt(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  int
+	}{
+		{name: "not retried", value: nil, want: 0},
+		{name: "retried", value: 2, want: 2},
+		{name: "restored from queue", value: float64(1), want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := colly.NewContext()
+			if tt.value != nil {
+				ctx.Put(attemptCtxKey, tt.value)
+			}
+
+			if got := requestAttempt(&colly.Request{Ctx: ctx}); got != tt.want {
+				t.Fatalf("got attempt %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyNext(t *testing.T) {
+	p := newRetryPolicy(RetryConfig{MaxAttempts: 100, BaseDelay: time.Second, MaxDelay: time.Minute})
+
+	tests := []struct {
+		name       string
+		attempt    int
+		status     int
+		retryAfter string
+		wantOK     bool
+		// delay is expected in [min, max]
+		min, max time.Duration
+	}{
+		{name: "first retry", attempt: 0, status: http.StatusBadGateway, wantOK: true, min: 500 * time.Millisecond, max: time.Second},
+		{name: "delay is doubled", attempt: 2, status: 0, wantOK: true, min: 2 * time.Second, max: 4 * time.Second},
+		{name: "delay is limited", attempt: 10, status: http.StatusBadGateway, wantOK: true, min: 30 * time.Second, max: time.Minute},
+		{name: "delay doesn't overflow", attempt: 98, status: http.StatusBadGateway, wantOK: true, min: 30 * time.Second, max: time.Minute},
+		{name: "retry after", attempt: 0, status: http.StatusTooManyRequests, retryAfter: "10", wantOK: true, min: 10 * time.Second, max: 10 * time.Second},
+		{name: "retry after is limited", attempt: 0, status: http.StatusTooManyRequests, retryAfter: "3600", wantOK: true, min: time.Minute, max: time.Minute},
+		{name: "attempts are exhausted", attempt: 99, status: http.StatusBadGateway},
+		{name: "not transient status", attempt: 0, status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := colly.NewContext()
+			ctx.Put(attemptCtxKey, tt.attempt)
+
+			headers := http.Header{}
+			if tt.retryAfter != "" {
+				headers.Set("Retry-After", tt.retryAfter)
+			}
+
+			delay, ok := p.next(&colly.Response{
+				Request:    &colly.Request{Ctx: ctx},
+				StatusCode: tt.status,
+				Headers:    &headers,
+			})
+
+			if ok != tt.wantOK || delay < tt.min || delay > tt.max {
+				t.Fatalf("got delay %s, retry %t, want delay in [%s, %s], retry %t", delay, ok, tt.min, tt.max, tt.wantOK)
+			}
+		})
+	}
+}
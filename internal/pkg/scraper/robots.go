@@ -0,0 +1,144 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/temoto/robotstxt"
+)
+
+// default robots.txt settings
+const (
+	defaultRobotsCacheTTL = 24 * time.Hour
+	robotsFailureTTL      = 5 * time.Minute
+	robotsFetchTimeout    = 10 * time.Second
+)
+
+// RobotsCache caches robots.txt per host, safe for concurrent use.
+// Files are fetched by client of the scraper, so its proxies and politeness limits apply.
+type RobotsCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*robotsEntry
+}
+
+// robotsEntry cached robots.txt of the host or error of its fetch
+type robotsEntry struct {
+	mu        sync.Mutex
+	data      *robotstxt.RobotsData
+	err       error
+	fetchedAt time.Time
+}
+
+// NewRobotsCache creates robots.txt cache, fetched files are refreshed after ttl
+func NewRobotsCache(ttl time.Duration) *RobotsCache {
+	if ttl <= 0 {
+		ttl = defaultRobotsCacheTTL
+	}
+
+	return &RobotsCache{
+		ttl:     ttl,
+		entries: make(map[string]*robotsEntry),
+	}
+}
+
+// Get returns robots.txt of the url's host, fetches it by client if not cached or expired.
+// Fetch error is cached for a short time, so unreachable robots.txt isn't requested before every page.
+func (rc *RobotsCache) Get(
+	ctx context.Context,
+	client *http.Client,
+	u *url.URL,
+	userAgent string,
+) (*robotstxt.RobotsData, error) {
+	key := u.Scheme + "://" + u.Host
+
+	rc.mu.Lock()
+	entry, ok := rc.entries[key]
+	if !ok {
+		entry = &robotsEntry{}
+		rc.entries[key] = entry
+	}
+	rc.mu.Unlock()
+
+	// lock per host, so robots.txt is fetched once
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	switch {
+	case entry.data != nil && time.Since(entry.fetchedAt) < rc.ttl:
+		return entry.data, nil
+	case entry.err != nil && time.Since(entry.fetchedAt) < robotsFailureTTL:
+		return nil, entry.err
+	}
+
+	data, err := rc.fetch(ctx, client, key+"/robots.txt", userAgent)
+
+	// fetch interrupted by scraper isn't a failure of the host
+	if err != nil && ctx.Err() != nil {
+		return nil, err
+	}
+
+	entry.data = data
+	entry.err = err
+	entry.fetchedAt = time.Now()
+
+	return data, err
+}
+
+// fetch downloads and parses robots.txt
+func (rc *RobotsCache) fetch(
+	ctx context.Context,
+	client *http.Client,
+	robotsURL string,
+	userAgent string,
+) (*robotstxt.RobotsData, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create robots.txt request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch robots.txt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := robotstxt.FromResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse robots.txt: %w", err)
+	}
+
+	return data, nil
+}
+
+// robotsClient returns client fetching robots.txt through the same proxies and politeness limits as pages
+func (s *Scraper) robotsClient() *http.Client {
+	return &http.Client{Transport: s.polite, Timeout: robotsFetchTimeout}
+}
+
+// checkRobots checks whether url is allowed by robots.txt and applies its crawl delay.
+// Url is allowed when robots.txt can't be fetched.
+func (s *Scraper) checkRobots(u *url.URL) (bool, error) {
+	data, err := s.robots.Get(s.ctx, s.robotsClient(), u, s.robotsUserAgent)
+	if err != nil {
+		return true, err
+	}
+
+	// query is matched too, so rules like "Disallow: /*?sort=" are applied
+	if !data.TestAgent(u.RequestURI(), s.robotsUserAgent) {
+		return false, nil
+	}
+
+	if delay := data.FindGroup(s.robotsUserAgent).CrawlDelay; delay > 0 {
+		s.polite.SetCrawlDelay(u.Host, delay)
+	}
+
+	return true, nil
+}
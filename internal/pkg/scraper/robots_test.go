@@ -0,0 +1,129 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+// roundTripFunc implements http.RoundTripper by function
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestRobotsCacheFailure(t *testing.T) {
+	unreachable := errors.New("host is unreachable")
+
+	var requests int
+	client := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		requests++
+		return nil, unreachable
+	})}
+
+	rc := NewRobotsCache(time.Hour)
+	u, _ := url.Parse("https://example.com/page")
+
+	tests := []struct {
+		name     string
+		ctx      func() context.Context
+		expire   bool
+		requests int
+	}{
+		{name: "fetch fails", ctx: context.Background, requests: 1},
+		{name: "failure is cached", ctx: context.Background, requests: 1},
+		{name: "failure expires", ctx: context.Background, expire: true, requests: 2},
+		{
+			name: "canceled fetch isn't cached",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			expire:   true,
+			requests: 3,
+		},
+		{name: "fetch after cancellation", ctx: context.Background, requests: 4},
+	}
+
+	for _, tt := range tests {
+		if tt.expire {
+			rc.entries["https://example.com"].fetchedAt = time.Now().Add(-robotsFailureTTL)
+		}
+
+		if _, err := rc.Get(tt.ctx(), client, u, "bot"); err == nil {
+			t.Fatalf("%s: expected error", tt.name)
+		}
+
+		if requests != tt.requests {
+			t.Fatalf("%s: robots.txt requested %d times, want %d", tt.name, requests, tt.requests)
+		}
+	}
+}
+
+func TestRobotsAreFetchedThroughProxy(t *testing.T) {
+	// proxy serves the site itself, site host isn't resolvable without it
+	proxy, log := newTestServer(t, func(w http.ResponseWriter, r *http.Request, _ int) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+		case "/":
+			fmt.Fprint(w, `<html><body><a href="/private">private</a><a href="/public">public</a></body></html>`)
+		default:
+			fmt.Fprint(w, `<html><body>page</body></html>`)
+		}
+	})
+
+	s := newTestScraper(t, func(cfg *Config) {
+		cfg.RespectRobotsTxt = true
+		cfg.ProxyURLs = []string{proxy.URL}
+	})
+
+	if err := s.VisitContext(context.Background(), "http://site.invalid/"); err != nil {
+		t.Fatalf("failed to visit: %v", err)
+	}
+
+	if got := log.get(); !slices.Equal(got, []string{"/robots.txt", "/", "/public"}) {
+		t.Fatalf("got requests %v, want robots.txt, root and public page", got)
+	}
+}
+
+func TestCheckRobots(t *testing.T) {
+	srv, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request, _ int) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private\nDisallow: /*?sort=\n")
+	})
+
+	s := newTestScraper(t, func(cfg *Config) {
+		cfg.RespectRobotsTxt = true
+	})
+	s.ctx = context.Background()
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "/public", want: true},
+		{path: "/private/page", want: false},
+		{path: "/list?page=2", want: true},
+		{path: "/list?sort=name", want: false},
+	}
+
+	for _, tt := range tests {
+		u, _ := url.Parse(srv.URL + tt.path)
+
+		allowed, err := s.checkRobots(u)
+		if err != nil {
+			t.Fatalf("failed to check robots: %v", err)
+		}
+
+		if allowed != tt.want {
+			t.Fatalf("%s: allowed=%t, want %t", tt.path, allowed, tt.want)
+		}
+	}
+}
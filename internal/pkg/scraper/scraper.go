@@ -1,38 +1,115 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gocolly/colly/v2"
 	"github.com/gocolly/colly/v2/queue"
+	"github.com/keenywheels/go-spy/pkg/logger"
 )
 
-// outputCallback is a callback function that processes output
-type outputCallback func(string)
+// outputCallback is a callback function that processes output, original contains
+// original forms of stemmed words if they are kept
+type outputCallback func(msg, original string)
+
+// CancelError is returned when scraping is stopped because context is done
+type CancelError struct {
+	Err error
+}
+
+// Error implements error interface
+func (e *CancelError) Error() string {
+	return fmt.Sprintf("scraping canceled: %v", e.Err)
+}
+
+// Unwrap returns cancellation cause
+func (e *CancelError) Unwrap() error {
+	return e.Err
+}
+
+// duplicateCtxKey request context key marking page as duplicate of visited canonical url
+const duplicateCtxKey = "duplicate"
+
+// interruptedCtxKey request context key marking request dropped because scraping is canceled
+const interruptedCtxKey = "interrupted"
+
+// queueStorage wraps queue storage and reports empty queue after stop,
+// so queue finishes in-flight requests and doesn't take stored ones
+type queueStorage struct {
+	queue.Storage
+	stopped atomic.Bool
+}
+
+// QueueSize returns zero after stop
+func (qs *queueStorage) QueueSize() (int, error) {
+	if qs.stopped.Load() {
+		return 0, nil
+	}
+
+	return qs.Storage.QueueSize()
+}
+
+// stop marks storage as stopped
+func (qs *queueStorage) stop() {
+	qs.stopped.Store(true)
+}
 
 // Scraper wrapper over gocolly package which provides scraper logic for html parse
 type Scraper struct {
-	c *colly.Collector
-	q *queue.Queue
+	c       *colly.Collector
+	qs      *queueStorage
+	threads int
+	polite  *politeTransport
+
+	frontierDir  string
+	queueMaxSize int
+	resumeMaxAge time.Duration
+	frontier     *frontier
+	runID        string
+
+	ctx    context.Context
+	logger logger.Logger
 
-	filter *regexp.Regexp
-	tags   string
+	filter         *regexp.Regexp
+	words          *wordFilter
+	tags           string
+	extractionMode string
+	outputMode     string
+	passageSize    int
 
 	siteName   string
 	siteDomain string
 	visited    map[string]struct{}
+	canon      *canonicalizer
+	budget     *budget
+	retries    *retryPolicy
+	pending    *pendingRetries
+	cache      *responseCache
+	urlFilter  *urlFilter
 
 	output      []string
+	original    []string
 	outputEvery int
 	isLogErrors bool
 
-	cb outputCallback
-	mu sync.Mutex
+	cb    outputCallback
+	docCb documentCallback
+	mu    sync.Mutex
 
-	headers map[string]string
+	headers   map[string]string
+	userAgent string
+
+	sitemap SitemapConfig
+
+	robots          *RobotsCache
+	robotsUserAgent string
 }
 
 // New creates new scraper instance with specified config
@@ -43,52 +120,126 @@ func New(cfg *Config) (*Scraper, error) {
 		return nil, fmt.Errorf("failed to create regexp: %w", err)
 	}
 
-	// configure colly collector
+	words, err := newWordFilter(cfg.Words)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create word filter: %w", err)
+	}
+
+	switch cfg.ExtractionMode {
+	case "", ExtractionTags, ExtractionReadability:
+	default:
+		return nil, fmt.Errorf("unknown extraction mode: %s", cfg.ExtractionMode)
+	}
+
+	switch cfg.OutputMode {
+	case "", OutputWords, OutputDocuments:
+	default:
+		return nil, fmt.Errorf("unknown output mode: %s", cfg.OutputMode)
+	}
+
+	canon, err := newCanonicalizer(cfg.Canonical, cfg.AllowedDomains)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create canonicalizer: %w", err)
+	}
+
+	urlFilter, err := newURLFilter(cfg.IncludePatterns, cfg.ExcludePatterns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create url filter: %w", err)
+	}
+
+	// configure colly collector, queue threads run requests concurrently themselves,
+	// so request is taken from queue only when thread is free
 	c := colly.NewCollector(
 		colly.UserAgent(cfg.UserAgent),
 		colly.MaxDepth(cfg.MaxDepth),
-		colly.Async(cfg.IsAsync),
+		colly.Async(cfg.IsAsync && !cfg.Queue.Enabled),
 	)
 
-	// if async then add limits
-	if cfg.IsAsync {
-		asyncDelay := cfg.AsyncDelay
-		if asyncDelay == 0 {
-			asyncDelay = defaultAsyncDelay
-		}
+	// set proxy rotation if proxies are configured
+	transport := http.DefaultTransport.(*http.Transport).Clone()
 
-		asyncRequestLimit := cfg.AsyncRequestLimit
-		if asyncRequestLimit == 0 {
-			asyncRequestLimit = defaultAsyncRequestLimit
-		}
+	var next http.RoundTripper = transport
 
-		c.Limit(&colly.LimitRule{
-			Parallelism: asyncRequestLimit,
-			RandomDelay: asyncDelay,
-		})
+	if len(cfg.ProxyURLs) > 0 {
+		next, err = newProxyPool(transport, cfg.ProxyURLs, cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create proxy pool: %w", err)
+		}
 	}
 
+	// politeness limits are applied by transport, so they work in any mode
+	polite := newPoliteTransport(next, politenessConfig(cfg))
+	c.WithTransport(polite)
+
 	// set queue if enabled
-	var q *queue.Queue
+	var qs *queueStorage
 
 	if cfg.Queue.Enabled {
-		q, _ = queue.New(
-			cfg.Queue.ThreadNumber,
-			&queue.InMemoryQueueStorage{MaxSize: cfg.Queue.MaxSize},
-		)
+		qs = &queueStorage{
+			Storage: &queue.InMemoryQueueStorage{MaxSize: cfg.Queue.MaxSize},
+		}
+
+		if err := qs.Init(); err != nil {
+			return nil, fmt.Errorf("failed to init queue storage: %w", err)
+		}
 	}
 
-	return &Scraper{
-		c:           c,
-		q:           q,
-		filter:      re,
-		tags:        strings.Join(cfg.TagsToParse, ", "),
-		headers:     cfg.Headers,
-		output:      make([]string, 0, cfg.OutputEvery),
-		outputEvery: cfg.OutputEvery,
-		isLogErrors: cfg.LogErrors,
-		cb:          defaultOutputCallback,
-	}, nil
+	s := &Scraper{
+		c:              c,
+		qs:             qs,
+		threads:        max(1, cfg.Queue.ThreadNumber),
+		frontierDir:    cfg.Queue.Dir,
+		queueMaxSize:   cfg.Queue.MaxSize,
+		resumeMaxAge:   cfg.Queue.ResumeMaxAge,
+		polite:         polite,
+		canon:          canon,
+		budget:         &budget{cfg: cfg.Budget},
+		retries:        newRetryPolicy(cfg.Retry),
+		pending:        newPendingRetries(),
+		urlFilter:      urlFilter,
+		filter:         re,
+		words:          words,
+		tags:           strings.Join(cfg.TagsToParse, ", "),
+		extractionMode: cfg.ExtractionMode,
+		outputMode:     cfg.OutputMode,
+		passageSize:    cfg.PassageSize,
+		headers:        cfg.Headers,
+		userAgent:      cfg.UserAgent,
+		output:         make([]string, 0, cfg.OutputEvery),
+		outputEvery:    cfg.OutputEvery,
+		isLogErrors:    cfg.LogErrors,
+		cb:             defaultOutputCallback,
+	}
+
+	// set response cache if enabled
+	if cfg.CacheDir != "" {
+		s.cache, err = newResponseCache(cfg.CacheDir, cfg.CacheExpiration)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create response cache: %w", err)
+		}
+	}
+
+	// set sitemap discovery if enabled
+	if cfg.Sitemap.Enabled {
+		s.sitemap = cfg.Sitemap
+		if s.sitemap.MaxURLs == 0 {
+			s.sitemap.MaxURLs = defaultSitemapMaxURLs
+		}
+		if s.sitemap.MaxDepth == 0 {
+			s.sitemap.MaxDepth = defaultSitemapMaxDepth
+		}
+	}
+
+	// set robots.txt policy if enabled
+	if cfg.RespectRobotsTxt {
+		s.robots = NewRobotsCache(cfg.RobotsCacheTTL)
+		s.robotsUserAgent = cfg.RobotsUserAgent
+		if s.robotsUserAgent == "" {
+			s.robotsUserAgent = cfg.UserAgent
+		}
+	}
+
+	return s, nil
 }
 
 // NewDefault creates new scraper using default config
@@ -102,6 +253,21 @@ func NewDefault() (*Scraper, error) {
 		return nil, fmt.Errorf("failed to create regexp: %w", err)
 	}
 
+	canon, err := newCanonicalizer(cfg.Canonical, cfg.AllowedDomains)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create canonicalizer: %w", err)
+	}
+
+	urlFilter, err := newURLFilter(cfg.IncludePatterns, cfg.ExcludePatterns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create url filter: %w", err)
+	}
+
+	words, err := newWordFilter(cfg.Words)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create word filter: %w", err)
+	}
+
 	// configure colly collector
 	c := colly.NewCollector(
 		colly.UserAgent(cfg.UserAgent),
@@ -109,14 +275,19 @@ func NewDefault() (*Scraper, error) {
 	)
 
 	// set limits
-	c.Limit(&colly.LimitRule{
-		Parallelism: defaultAsyncRequestLimit,
-		RandomDelay: defaultAsyncDelay,
-	})
+	polite := newPoliteTransport(http.DefaultTransport.(*http.Transport).Clone(), politenessConfig(cfg))
+	c.WithTransport(polite)
 
 	return &Scraper{
 		c:           c,
+		polite:      polite,
+		canon:       canon,
+		budget:      &budget{cfg: cfg.Budget},
+		retries:     newRetryPolicy(cfg.Retry),
+		pending:     newPendingRetries(),
+		urlFilter:   urlFilter,
 		filter:      re,
+		words:       words,
 		tags:        strings.Join(cfg.TagsToParse, ", "),
 		headers:     cfg.Headers,
 		output:      make([]string, 0, cfg.OutputEvery),
@@ -126,6 +297,6 @@ func NewDefault() (*Scraper, error) {
 }
 
 // defaultOutputCallback is the default output callback function
-func defaultOutputCallback(msg string) {
+func defaultOutputCallback(msg, _ string) {
 	fmt.Printf("RESULT: %s\n", msg)
 }
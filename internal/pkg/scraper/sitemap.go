@@ -0,0 +1,183 @@
+package scraper
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// default sitemap settings
+const (
+	defaultSitemapMaxURLs  = 50000
+	defaultSitemapMaxDepth = 3
+	sitemapFetchTimeout    = 30 * time.Second
+	// sitemapMaxSize is maximum size of uncompressed sitemap according to protocol
+	sitemapMaxSize = 50 << 20
+)
+
+// sitemapLastModLayouts layouts of W3C datetime used in lastmod
+var sitemapLastModLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+}
+
+// sitemapEntry url or sitemap entry of sitemap file
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// sitemapFile is urlset or sitemapindex file
+type sitemapFile struct {
+	URLs     []sitemapEntry `xml:"url"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+// discoverSitemaps returns sitemap urls from robots.txt and default /sitemap.xml
+func (s *Scraper) discoverSitemaps(ctx context.Context, root *url.URL) []string {
+	sitemaps := make([]string, 0, 1)
+
+	// robots.txt is fetched for sitemaps even if its rules aren't respected
+	rc, userAgent := s.robots, s.robotsUserAgent
+	if rc == nil {
+		rc, userAgent = NewRobotsCache(0), s.userAgent
+	}
+
+	if data, err := rc.Get(ctx, s.robotsClient(), root, userAgent); err == nil {
+		sitemaps = append(sitemaps, data.Sitemaps...)
+	}
+
+	return append(sitemaps, root.Scheme+"://"+root.Host+"/sitemap.xml")
+}
+
+// sitemapURLs collects page urls from site sitemaps, nested sitemap indexes are followed,
+// discovered sitemaps have depth 0 and sitemaps listed in them have depth 1
+func (s *Scraper) sitemapURLs(ctx context.Context, root *url.URL) ([]string, error) {
+	var (
+		urls    = make([]string, 0)
+		seen    = make(map[string]struct{})
+		errs    []string
+		pending = s.discoverSitemaps(ctx, root)
+	)
+
+	for depth := 0; len(pending) > 0 && depth <= s.sitemap.MaxDepth; depth++ {
+		next := make([]string, 0)
+
+		for _, loc := range pending {
+			if _, ok := seen[loc]; ok {
+				continue
+			}
+			seen[loc] = struct{}{}
+
+			file, err := s.fetchSitemap(ctx, loc)
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+
+			for _, e := range file.Sitemaps {
+				if s.isSitemapEntryFresh(e) {
+					next = append(next, strings.TrimSpace(e.Loc))
+				}
+			}
+
+			for _, e := range file.URLs {
+				if len(urls) >= s.sitemap.MaxURLs {
+					return urls, nil
+				}
+
+				if s.isSitemapEntryFresh(e) {
+					urls = append(urls, strings.TrimSpace(e.Loc))
+				}
+			}
+		}
+
+		pending = next
+	}
+
+	// sitemaps are optional, so error is returned only if nothing found
+	if len(urls) == 0 && len(errs) > 0 {
+		return nil, fmt.Errorf("failed to get sitemaps: %s", strings.Join(errs, "; "))
+	}
+
+	return urls, nil
+}
+
+// fetchSitemap downloads and parses sitemap, gzipped sitemaps are decompressed
+func (s *Scraper) fetchSitemap(ctx context.Context, loc string) (*sitemapFile, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, loc, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sitemap request url=%s: %w", loc, err)
+	}
+
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("User-Agent", s.userAgent)
+	// transport decompresses gzip transfer itself only if encoding isn't set manually
+	req.Header.Del("Accept-Encoding")
+
+	// sitemaps are fetched through the same proxies and politeness limits as pages
+	client := http.Client{Transport: s.polite, Timeout: sitemapFetchTimeout}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap url=%s: %w", loc, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch sitemap url=%s: status %d", loc, resp.StatusCode)
+	}
+
+	body := bufio.NewReader(io.LimitReader(resp.Body, sitemapMaxSize))
+
+	var r io.Reader = body
+
+	// .xml.gz files are served as is, so check gzip magic bytes
+	if magic, err := body.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress sitemap url=%s: %w", loc, err)
+		}
+		defer gz.Close()
+
+		r = io.LimitReader(gz, sitemapMaxSize)
+	}
+
+	var file sitemapFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse sitemap url=%s: %w", loc, err)
+	}
+
+	return &file, nil
+}
+
+// isSitemapEntryFresh checks entry lastmod against configured age,
+// entries without valid lastmod are considered fresh
+func (s *Scraper) isSitemapEntryFresh(e sitemapEntry) bool {
+	if strings.TrimSpace(e.Loc) == "" {
+		return false
+	}
+
+	if s.sitemap.MaxAge <= 0 || e.LastMod == "" {
+		return true
+	}
+
+	for _, layout := range sitemapLastModLayouts {
+		lastMod, err := time.Parse(layout, strings.TrimSpace(e.LastMod))
+		if err == nil {
+			return time.Since(lastMod) <= s.sitemap.MaxAge
+		}
+	}
+
+	return true
+}
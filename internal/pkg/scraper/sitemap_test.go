@@ -0,0 +1,103 @@
+package scraper
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sitemapIndex returns sitemap index listing specified sitemaps
+func sitemapIndex(base string, locs ...string) string {
+	var b strings.Builder
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><sitemapindex>`)
+	for _, loc := range locs {
+		fmt.Fprintf(&b, `<sitemap><loc>%s%s</loc></sitemap>`, base, loc)
+	}
+	b.WriteString(`</sitemapindex>`)
+
+	return b.String()
+}
+
+func TestSitemapURLs(t *testing.T) {
+	var base string
+
+	srv, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request, _ int) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprint(w, sitemapIndex(base, "/nested.xml", "/top.xml"))
+		case "/top.xml":
+			fmt.Fprintf(w, `<urlset><url><loc>%[1]s/top</loc></url>`+
+				`<url><loc>%[1]s/old</loc><lastmod>2000-01-01</lastmod></url></urlset>`, base)
+		case "/nested.xml":
+			fmt.Fprint(w, sitemapIndex(base, "/pages.xml.gz"))
+		case "/pages.xml.gz":
+			// gzipped sitemap is served as is, without content encoding
+			gz := gzip.NewWriter(w)
+			fmt.Fprintf(gz, `<urlset><url><loc>%[1]s/a</loc></url><url><loc>%[1]s/b</loc></url></urlset>`, base)
+			gz.Close()
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	base = srv.URL
+
+	tests := []struct {
+		name string
+		cfg  SitemapConfig
+		want []string
+	}{
+		{name: "nested index isn't followed", cfg: SitemapConfig{MaxDepth: 1}, want: []string{"/top", "/old"}},
+		{name: "nested gzipped sitemap", cfg: SitemapConfig{MaxDepth: 2}, want: []string{"/top", "/old", "/a", "/b"}},
+		{name: "default depth", cfg: SitemapConfig{}, want: []string{"/top", "/old", "/a", "/b"}},
+		{name: "max urls", cfg: SitemapConfig{MaxDepth: 2, MaxURLs: 3}, want: []string{"/top", "/old", "/a"}},
+		{name: "stale urls are skipped", cfg: SitemapConfig{MaxDepth: 2, MaxAge: 24 * time.Hour}, want: []string{"/top", "/a", "/b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestScraper(t, func(cfg *Config) {
+				cfg.Sitemap = tt.cfg
+				cfg.Sitemap.Enabled = true
+			})
+
+			root, _ := url.Parse(srv.URL + "/")
+
+			urls, err := s.sitemapURLs(context.Background(), root)
+			if err != nil {
+				t.Fatalf("failed to get sitemap urls: %v", err)
+			}
+
+			got := make([]string, 0, len(urls))
+			for _, u := range urls {
+				got = append(got, strings.TrimPrefix(u, srv.URL))
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSitemapURLsMissingSitemap(t *testing.T) {
+	srv, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request, _ int) {
+		http.NotFound(w, r)
+	})
+
+	s := newTestScraper(t, func(cfg *Config) {
+		cfg.Sitemap = SitemapConfig{Enabled: true}
+	})
+
+	root, _ := url.Parse(srv.URL + "/")
+
+	if _, err := s.sitemapURLs(context.Background(), root); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,141 @@
+package scraper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gobwas/glob"
+)
+
+// globPrefix marks pattern as glob, other patterns are regular expressions
+const globPrefix = "glob:"
+
+// url rule kinds
+const (
+	// RuleInclude link is rejected because it doesn't match include pattern,
+	// link is dropped only if it's rejected by every include pattern
+	RuleInclude = "include"
+	// RuleExclude link is dropped because it matches exclude pattern
+	RuleExclude = "exclude"
+)
+
+// FilterStat shows how many links were dropped by url rule
+type FilterStat struct {
+	// Kind is include or exclude
+	Kind string
+	// Pattern is rule pattern
+	Pattern string
+	// Dropped is number of links dropped by exclude rule or rejected by include rule
+	Dropped int64
+}
+
+// urlRule compiled url pattern with dropped or rejected links counter
+type urlRule struct {
+	pattern string
+	match   func(string) bool
+	dropped atomic.Int64
+}
+
+// urlFilter filters canonical urls by include and exclude patterns
+type urlFilter struct {
+	include []*urlRule
+	exclude []*urlRule
+}
+
+// newURLFilter compiles include and exclude patterns
+func newURLFilter(include, exclude []string) (*urlFilter, error) {
+	f := urlFilter{
+		include: make([]*urlRule, 0, len(include)),
+		exclude: make([]*urlRule, 0, len(exclude)),
+	}
+
+	for _, p := range include {
+		rule, err := newURLRule(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile include pattern: %w", err)
+		}
+
+		f.include = append(f.include, rule)
+	}
+
+	for _, p := range exclude {
+		rule, err := newURLRule(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile exclude pattern: %w", err)
+		}
+
+		f.exclude = append(f.exclude, rule)
+	}
+
+	return &f, nil
+}
+
+// newURLRule compiles regexp or glob pattern
+func newURLRule(pattern string) (*urlRule, error) {
+	if g, ok := strings.CutPrefix(pattern, globPrefix); ok {
+		compiled, err := glob.Compile(g)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", g, err)
+		}
+
+		return &urlRule{pattern: pattern, match: compiled.Match}, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regexp %q: %w", pattern, err)
+	}
+
+	return &urlRule{pattern: pattern, match: re.MatchString}, nil
+}
+
+// allow checks url against rules, exclude rules win over include ones
+func (f *urlFilter) allow(link string) bool {
+	for _, rule := range f.exclude {
+		if rule.match(link) {
+			rule.dropped.Add(1)
+			return false
+		}
+	}
+
+	if len(f.include) == 0 {
+		return true
+	}
+
+	// every include rule is checked, so each of them counts links it rejects
+	included := false
+	for _, rule := range f.include {
+		if rule.match(link) {
+			included = true
+		} else {
+			rule.dropped.Add(1)
+		}
+	}
+
+	return included
+}
+
+// stats returns dropped links counters
+func (f *urlFilter) stats() []FilterStat {
+	stats := make([]FilterStat, 0, len(f.include)+len(f.exclude))
+
+	for _, rule := range f.include {
+		stats = append(stats, FilterStat{
+			Kind:    RuleInclude,
+			Pattern: rule.pattern,
+			Dropped: rule.dropped.Load(),
+		})
+	}
+
+	for _, rule := range f.exclude {
+		stats = append(stats, FilterStat{
+			Kind:    RuleExclude,
+			Pattern: rule.pattern,
+			Dropped: rule.dropped.Load(),
+		})
+	}
+
+	return stats
+}
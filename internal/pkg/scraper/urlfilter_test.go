@@ -0,0 +1,94 @@
+package scraper
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestURLFilterAllow(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		link    string
+		want    bool
+	}{
+		{name: "no rules", link: "https://example.com/a", want: true},
+		{name: "regexp include", include: []string{`^https://example\.com/blog/`}, link: "https://example.com/blog/post", want: true},
+		{name: "regexp include mismatch", include: []string{`^https://example\.com/blog/`}, link: "https://example.com/shop", want: false},
+		{name: "glob include", include: []string{"glob:https://example.com/docs/*"}, link: "https://example.com/docs/intro", want: true},
+		{name: "glob include mismatch", include: []string{"glob:https://example.com/docs/*"}, link: "https://example.com/blog/post", want: false},
+		{name: "any include matches", include: []string{"/blog/", "glob:*/docs/*"}, link: "https://example.com/docs/intro", want: true},
+		{name: "regexp exclude", exclude: []string{`\.pdf$`}, link: "https://example.com/file.pdf", want: false},
+		{name: "glob exclude", exclude: []string{"glob:*?page=*"}, link: "https://example.com/list?page=2", want: false},
+		{name: "exclude mismatch", exclude: []string{`\.pdf$`}, link: "https://example.com/file.html", want: true},
+		{
+			name:    "exclude wins over include",
+			include: []string{"/blog/"},
+			exclude: []string{"glob:*/blog/drafts/*"},
+			link:    "https://example.com/blog/drafts/post",
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newURLFilter(tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("failed to create filter: %v", err)
+			}
+
+			if got := f.allow(tt.link); got != tt.want {
+				t.Fatalf("allow(%q) = %t, want %t", tt.link, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewURLFilterInvalidPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+	}{
+		{name: "invalid include regexp", include: []string{"("}},
+		{name: "invalid exclude glob", exclude: []string{"glob:[a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newURLFilter(tt.include, tt.exclude); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestURLFilterStats(t *testing.T) {
+	f, err := newURLFilter([]string{"/blog/", "glob:*/docs/*"}, []string{`\.pdf$`, "glob:*/drafts/*"})
+	if err != nil {
+		t.Fatalf("failed to create filter: %v", err)
+	}
+
+	for _, link := range []string{
+		"https://example.com/blog/post",
+		"https://example.com/docs/intro",
+		"https://example.com/docs/guide.pdf",
+		"https://example.com/blog/drafts/post",
+		"https://example.com/shop",
+	} {
+		f.allow(link)
+	}
+
+	// excluded links aren't checked by include rules
+	want := []FilterStat{
+		{Kind: RuleInclude, Pattern: "/blog/", Dropped: 2},
+		{Kind: RuleInclude, Pattern: "glob:*/docs/*", Dropped: 2},
+		{Kind: RuleExclude, Pattern: `\.pdf$`, Dropped: 1},
+		{Kind: RuleExclude, Pattern: "glob:*/drafts/*", Dropped: 1},
+	}
+
+	if got := f.stats(); !slices.Equal(got, want) {
+		t.Fatalf("got stats %+v, want %+v", got, want)
+	}
+}
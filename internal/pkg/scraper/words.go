@@ -0,0 +1,136 @@
+package scraper
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/keenywheels/go-spy/internal/pkg/nlp"
+)
+
+// wordFilter drops stopwords, words of unsuitable length and words which are found on every page,
+// then optionally stems words
+type wordFilter struct {
+	stopwords   map[string]struct{}
+	minLength   int
+	maxLength   int
+	commonAfter int
+	// language is stemming language, empty if stemming is disabled
+	language     string
+	keepOriginal bool
+
+	mu       sync.Mutex
+	pages    int
+	lastPage map[string]uint32
+	pageFreq map[string]int
+}
+
+// newWordFilter creates word filter from config
+func newWordFilter(cfg WordsConfig) (*wordFilter, error) {
+	f := &wordFilter{
+		stopwords:    make(map[string]struct{}),
+		minLength:    cfg.MinLength,
+		maxLength:    cfg.MaxLength,
+		commonAfter:  cfg.DropCommonAfter,
+		keepOriginal: cfg.Stem && cfg.KeepOriginal,
+		lastPage:     make(map[string]uint32),
+		pageFreq:     make(map[string]int),
+	}
+
+	for _, lang := range cfg.Stopwords {
+		words, ok := nlp.Stopwords(lang)
+		if !ok {
+			return nil, fmt.Errorf("unknown stopwords language: %s", lang)
+		}
+
+		for _, w := range words {
+			f.stopwords[w] = struct{}{}
+		}
+	}
+
+	for _, w := range cfg.CustomStopwords {
+		f.stopwords[nlp.Normalize(w)] = struct{}{}
+	}
+
+	if cfg.Stem {
+		switch cfg.Language {
+		case "":
+			f.language = nlp.LangAuto
+		case nlp.LangRussian, nlp.LangEnglish, nlp.LangAuto:
+			f.language = cfg.Language
+		default:
+			return nil, fmt.Errorf("unknown stemming language: %s", cfg.Language)
+		}
+	}
+
+	return f, nil
+}
+
+// keep shows whether normalized word passes stopwords and length limits
+func (f *wordFilter) keep(w string) bool {
+	if _, ok := f.stopwords[w]; ok {
+		return false
+	}
+
+	n := utf8.RuneCountInString(w)
+
+	return n >= f.minLength && (f.maxLength <= 0 || n <= f.maxLength)
+}
+
+// addPage counts parsed page, must be called before words of the page are filtered
+func (f *wordFilter) addPage() {
+	if f.commonAfter <= 0 {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.pages++
+}
+
+// dropCommon counts words of the page and removes words found on every page,
+// words are removed only after commonAfter pages are parsed
+func (f *wordFilter) dropCommon(page uint32, words []string) []string {
+	if f.commonAfter <= 0 {
+		return words
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	kept := words[:0]
+	for _, w := range words {
+		if f.lastPage[w] != page {
+			f.lastPage[w] = page
+			f.pageFreq[w]++
+		}
+
+		if f.pages >= f.commonAfter && f.pageFreq[w] >= f.pages {
+			continue
+		}
+
+		kept = append(kept, w)
+	}
+
+	return kept
+}
+
+// stem replaces words by their stems in place, returns copy of original words if they are kept
+func (f *wordFilter) stem(words []string) []string {
+	if f.language == "" {
+		return nil
+	}
+
+	var original []string
+	if f.keepOriginal {
+		original = slices.Clone(words)
+	}
+
+	for i, w := range words {
+		words[i] = nlp.Stem(w, f.language)
+	}
+
+	return original
+}
@@ -0,0 +1,142 @@
+package scraper
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWordFilterKeep(t *testing.T) {
+	f, err := newWordFilter(WordsConfig{
+		Stopwords:       []string{"en"},
+		CustomStopwords: []string{"Ёлка"},
+		MinLength:       2,
+		MaxLength:       6,
+	})
+	if err != nil {
+		t.Fatalf("failed to create word filter: %v", err)
+	}
+
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{word: "golang", want: true},
+		{word: "the", want: false},
+		{word: "елка", want: false},
+		{word: "a", want: false},
+		{word: "go", want: true},
+		{word: "channels", want: false},
+		// length is counted in characters
+		{word: "привет", want: true},
+	}
+
+	for _, tt := range tests {
+		if got := f.keep(tt.word); got != tt.want {
+			t.Fatalf("keep(%q) = %t, want %t", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestNewWordFilterUnknownLanguage(t *testing.T) {
+	if _, err := newWordFilter(WordsConfig{Stopwords: []string{"de"}}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestWordFilterDropCommon(t *testing.T) {
+	tests := []struct {
+		name        string
+		commonAfter int
+		pages       [][]string
+		want        [][]string
+	}{
+		{
+			name:        "disabled",
+			commonAfter: 0,
+			pages:       [][]string{{"menu", "go"}, {"menu", "rust"}},
+			want:        [][]string{{"menu", "go"}, {"menu", "rust"}},
+		},
+		{
+			name:        "word found on every page",
+			commonAfter: 2,
+			pages:       [][]string{{"menu", "go"}, {"menu", "rust"}, {"menu", "go", "go"}},
+			want:        [][]string{{"menu", "go"}, {"rust"}, {"go", "go"}},
+		},
+		{
+			name:        "word missing on a page",
+			commonAfter: 2,
+			pages:       [][]string{{"menu", "go"}, {"go"}, {"menu", "go"}},
+			want:        [][]string{{"menu", "go"}, {}, {"menu"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newWordFilter(WordsConfig{DropCommonAfter: tt.commonAfter})
+			if err != nil {
+				t.Fatalf("failed to create word filter: %v", err)
+			}
+
+			for i, words := range tt.pages {
+				f.addPage()
+
+				if got := f.dropCommon(uint32(i+1), slices.Clone(words)); !slices.Equal(got, tt.want[i]) {
+					t.Fatalf("page %d: got %q, want %q", i+1, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWordFilterStem(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      WordsConfig
+		want     []string
+		original []string
+	}{
+		{
+			name: "disabled",
+			cfg:  WordsConfig{},
+			want: []string{"running", "книгами"},
+		},
+		{
+			name: "auto language",
+			cfg:  WordsConfig{Stem: true},
+			want: []string{"run", "книг"},
+		},
+		{
+			name: "fixed language",
+			cfg:  WordsConfig{Stem: true, Language: "en"},
+			want: []string{"run", "книгами"},
+		},
+		{
+			name:     "original words are kept",
+			cfg:      WordsConfig{Stem: true, KeepOriginal: true},
+			want:     []string{"run", "книг"},
+			original: []string{"running", "книгами"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newWordFilter(tt.cfg)
+			if err != nil {
+				t.Fatalf("failed to create word filter: %v", err)
+			}
+
+			words := []string{"running", "книгами"}
+			original := f.stem(words)
+
+			if !slices.Equal(words, tt.want) || !slices.Equal(original, tt.original) {
+				t.Fatalf("got %q and original %q, want %q and %q", words, original, tt.want, tt.original)
+			}
+		})
+	}
+}
+
+func TestNewWordFilterUnknownStemmingLanguage(t *testing.T) {
+	if _, err := newWordFilter(WordsConfig{Stem: true, Language: "de"}); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -2,14 +2,19 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"expvar"
 	"fmt"
 	"log"
 	"net/http"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
 	"github.com/keenywheels/go-spy/internal/pkg/producer/kafka"
 	"github.com/keenywheels/go-spy/internal/scheduler/repository/broker"
+	"github.com/keenywheels/go-spy/internal/scheduler/repository/outbox"
+	"github.com/keenywheels/go-spy/internal/scheduler/repository/sink"
 	"github.com/keenywheels/go-spy/internal/scheduler/service"
 	"github.com/keenywheels/go-spy/pkg/logger"
 	"github.com/keenywheels/go-spy/pkg/logger/zap"
@@ -18,6 +23,15 @@ import (
 	_ "net/http/pprof"
 )
 
+// sinkTypeKafka type of the kafka sink
+const sinkTypeKafka = "kafka"
+
+// async kafka delivery counters exposed on system server
+var (
+	kafkaDelivered = expvar.NewInt("kafka_delivered")
+	kafkaFailed    = expvar.NewInt("kafka_failed")
+)
+
 // App represent app environment
 type App struct {
 	opts *Options
@@ -67,27 +81,75 @@ func (app *App) Run() error {
 		})
 	}
 
-	// create broker
-	kafka, err := kafka.New(cfg.KafkaCfg.Brokers, kafka.Config{
-		MaxRetry: cfg.KafkaCfg.MaxRetry,
-	})
+	// every sink has its own outbox, so failed events are replayed only to sinks which failed them
+	outboxes := make(map[string]*outbox.Outbox)
+
+	var wrap sink.Wrapper
+	if cfg.OutboxCfg.Enabled {
+		wrap = func(sc sink.Config, s sink.Sink) (sink.Sink, error) {
+			ob, err := outbox.New(outbox.Config{
+				Dir:            filepath.Join(cfg.OutboxCfg.Dir, sc.ID()),
+				SegmentSize:    cfg.OutboxCfg.SegmentSize,
+				MaxSize:        cfg.OutboxCfg.MaxSize,
+				Eviction:       cfg.OutboxCfg.Eviction,
+				ReplayInterval: cfg.OutboxCfg.ReplayInterval,
+			}, s, app.logger.With(logger.Field{Key: "sink", Value: sc.ID()}))
+			if err != nil {
+				return nil, fmt.Errorf("failed to create outbox: %w", err)
+			}
+
+			outboxes[sc.ID()] = ob
+
+			return &outboxSink{Outbox: ob, next: s}, nil
+		}
+	}
+
+	// create output sinks
+	out, err := app.initSinks(ctx, wrap)
 	if err != nil {
-		return fmt.Errorf("failed to create kafka producer: %w", err)
+		return fmt.Errorf("failed to create sinks: %w", err)
 	}
+	defer func() {
+		if err := out.Close(); err != nil {
+			app.logger.Errorf("failed to close sinks: %v", err)
+		}
+	}()
 
-	broker := broker.New(kafka, broker.Topics{
-		ScraperData: cfg.KafkaCfg.Topics.ScraperData,
-	})
+	if len(outboxes) != 0 {
+		// backlog depth of every sink is exposed on system server
+		expvar.Publish("outbox_depth", expvar.Func(func() any {
+			return outboxStats(outboxes, (*outbox.Outbox).Depth)
+		}))
+		expvar.Publish("outbox_size", expvar.Func(func() any {
+			return outboxStats(outboxes, (*outbox.Outbox).Size)
+		}))
+		expvar.Publish("outbox_dropped", expvar.Func(func() any {
+			return outboxStats(outboxes, (*outbox.Outbox).Dropped)
+		}))
+
+		for name, ob := range outboxes {
+			g.Go(func() error {
+				app.logger.Infof("starting outbox replay for sink %s, backlog depth: %d", name, ob.Depth())
+				return ob.Run(ctx)
+			})
+		}
+	}
 
 	// create service layer
 	srv, err := service.New(
 		ctx,
 		app.logger,
 		&app.cfg.SchedulerCfg.ScraperCfg,
-		app.cfg.SchedulerCfg.CronPattern,
-		app.cfg.SchedulerCfg.WorkersCount,
+		service.Config{
+			CronPattern:     app.cfg.SchedulerCfg.CronPattern,
+			WorkersCount:    app.cfg.SchedulerCfg.WorkersCount,
+			OverlapMode:     app.cfg.SchedulerCfg.OverlapMode,
+			MaxRunDuration:  app.cfg.SchedulerCfg.MaxRunDuration,
+			SiteTimeout:     app.cfg.SchedulerCfg.SiteTimeout,
+			ShutdownTimeout: app.cfg.SchedulerCfg.ShutdownTimeout,
+		},
 		app.cfg.SchedulerCfg.Sites,
-		broker,
+		out,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create service layer: %w", err)
@@ -108,6 +170,86 @@ func (app *App) Run() error {
 	return nil
 }
 
+// outboxSink sends events to sink through its outbox
+type outboxSink struct {
+	*outbox.Outbox
+	next sink.Sink
+}
+
+// Close closes sink first, so outbox isn't closed while sink stores undelivered event
+func (s *outboxSink) Close() error {
+	return errors.Join(s.next.Close(), s.Outbox.Close())
+}
+
+// outboxStats returns stat of every outbox by sink name
+func outboxStats[T any](outboxes map[string]*outbox.Outbox, stat func(*outbox.Outbox) T) map[string]T {
+	res := make(map[string]T, len(outboxes))
+	for name, ob := range outboxes {
+		res[name] = stat(ob)
+	}
+
+	return res
+}
+
+// initSinks creates output sinks based on config, kafka is used if no sinks configured
+func (app *App) initSinks(ctx context.Context, wrap sink.Wrapper) (sink.Sink, error) {
+	registry := sink.NewRegistry(ctx)
+
+	registry.Register(sinkTypeKafka, func(_ context.Context, _ sink.Config, _ logger.Logger) (sink.Sink, error) {
+		kafka, err := app.newKafkaProducer()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kafka producer: %w", err)
+		}
+
+		return broker.New(kafka, broker.Topics{
+			ScraperData: app.cfg.KafkaCfg.Topics.ScraperData,
+		}), nil
+	})
+
+	cfgs := app.cfg.Sinks
+	if len(cfgs) == 0 {
+		cfgs = []sink.Config{{Type: sinkTypeKafka}}
+	}
+
+	return registry.Build(cfgs, app.logger, wrap)
+}
+
+// newKafkaProducer creates kafka producer based on config
+func (app *App) newKafkaProducer() (*kafka.Kafka, error) {
+	kafkaCfg := app.cfg.KafkaCfg
+
+	return kafka.New(kafkaCfg.Brokers, kafka.Config{
+		ClientID: kafkaCfg.ClientID,
+		Version:  kafkaCfg.Version,
+		TLS: kafka.TLSConfig{
+			Enabled:            kafkaCfg.TLS.Enabled,
+			CAFile:             kafkaCfg.TLS.CAFile,
+			CertFile:           kafkaCfg.TLS.CertFile,
+			KeyFile:            kafkaCfg.TLS.KeyFile,
+			InsecureSkipVerify: kafkaCfg.TLS.InsecureSkipVerify,
+		},
+		SASL: kafka.SASLConfig{
+			Enabled:   kafkaCfg.SASL.Enabled,
+			Mechanism: kafkaCfg.SASL.Mechanism,
+			User:      kafkaCfg.SASL.User,
+			Password:  kafkaCfg.SASL.Password,
+		},
+		MaxRetry:    kafkaCfg.MaxRetry,
+		Async:       kafkaCfg.Async,
+		BatchSize:   kafkaCfg.BatchSize,
+		Linger:      kafkaCfg.Linger,
+		MaxInFlight: kafkaCfg.MaxInFlight,
+		Compression: kafkaCfg.Compression,
+		OnDelivered: func(_ kafka.Message) {
+			kafkaDelivered.Add(1)
+		},
+		OnFailed: func(msg kafka.Message, err error) {
+			kafkaFailed.Add(1)
+			app.logger.Errorf("failed to deliver scraper event of site %s to topic %s: %v", msg.Key, msg.Topic, err)
+		},
+	}, app.logger)
+}
+
 // initLogger create new Logger based on config
 func (app *App) initLogger() {
 	logCfg := app.cfg.SchedulerCfg.LoggerCfg
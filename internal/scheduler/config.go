@@ -1,10 +1,13 @@
 package scheduler
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/keenywheels/go-spy/internal/pkg/scraper"
+	"github.com/keenywheels/go-spy/internal/scheduler/repository/sink"
 	"github.com/keenywheels/go-spy/internal/scheduler/service"
 	"github.com/spf13/viper"
 )
@@ -28,12 +31,16 @@ type SystemServerConfig struct {
 
 // AppConfig contains all configs which connected to main app
 type AppConfig struct {
-	CronPattern  string             `mapstructure:"cron_pattern"`
-	WorkersCount int                `mapstructure:"workers_count"`
-	Sites        []service.Site     `mapstructure:"sites"`
-	LoggerCfg    LoggerConfig       `mapstructure:"logger"`
-	ScraperCfg   scraper.Config     `mapstructure:"scraper"`
-	SysSrvCfg    SystemServerConfig `mapstructure:"system_server"`
+	CronPattern     string             `mapstructure:"cron_pattern"`
+	WorkersCount    int                `mapstructure:"workers_count"`
+	OverlapMode     string             `mapstructure:"overlap_mode"`
+	MaxRunDuration  time.Duration      `mapstructure:"max_run_duration"`
+	SiteTimeout     time.Duration      `mapstructure:"site_timeout"`
+	ShutdownTimeout time.Duration      `mapstructure:"shutdown_timeout"`
+	Sites           []service.Site     `mapstructure:"sites"`
+	LoggerCfg       LoggerConfig       `mapstructure:"logger"`
+	ScraperCfg      scraper.Config     `mapstructure:"scraper"`
+	SysSrvCfg       SystemServerConfig `mapstructure:"system_server"`
 }
 
 // KafkaTopics contains all kafka topics
@@ -41,17 +48,55 @@ type KafkaTopics struct {
 	ScraperData string `mapstructure:"scraper_data"`
 }
 
+// KafkaTLSConfig contains TLS config for kafka
+type KafkaTLSConfig struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	CAFile             string `mapstructure:"ca_file"`
+	CertFile           string `mapstructure:"cert_file"`
+	KeyFile            string `mapstructure:"key_file"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+// KafkaSASLConfig contains SASL config for kafka
+type KafkaSASLConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	Mechanism string `mapstructure:"mechanism"`
+	User      string `mapstructure:"user"`
+	Password  string `mapstructure:"password"`
+}
+
 // KafkaConfig contains config for kafka
 type KafkaConfig struct {
-	MaxRetry int         `mapstructure:"max_retry"`
-	Brokers  []string    `mapstructure:"brokers"`
-	Topics   KafkaTopics `mapstructure:"topics"`
+	ClientID    string          `mapstructure:"client_id"`
+	Version     string          `mapstructure:"version"`
+	TLS         KafkaTLSConfig  `mapstructure:"tls"`
+	SASL        KafkaSASLConfig `mapstructure:"sasl"`
+	MaxRetry    int             `mapstructure:"max_retry"`
+	Async       bool            `mapstructure:"async"`
+	BatchSize   int             `mapstructure:"batch_size"`
+	Linger      time.Duration   `mapstructure:"linger"`
+	MaxInFlight int             `mapstructure:"max_in_flight"`
+	Compression string          `mapstructure:"compression"`
+	Brokers     []string        `mapstructure:"brokers"`
+	Topics      KafkaTopics     `mapstructure:"topics"`
+}
+
+// OutboxConfig contains config for disk outbox
+type OutboxConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	Dir            string        `mapstructure:"dir"`
+	SegmentSize    int64         `mapstructure:"segment_size"`
+	MaxSize        int64         `mapstructure:"max_size"`
+	Eviction       string        `mapstructure:"eviction"`
+	ReplayInterval time.Duration `mapstructure:"replay_interval"`
 }
 
 // Config global config, contains all configs
 type Config struct {
-	SchedulerCfg AppConfig   `mapstructure:"scheduler"`
-	KafkaCfg     KafkaConfig `mapstructure:"kafka"`
+	SchedulerCfg AppConfig     `mapstructure:"scheduler"`
+	KafkaCfg     KafkaConfig   `mapstructure:"kafka"`
+	OutboxCfg    OutboxConfig  `mapstructure:"outbox"`
+	Sinks        []sink.Config `mapstructure:"sinks"`
 }
 
 // LoadConfig
@@ -73,5 +118,34 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
 	return &cfg, nil
 }
+
+// validate checks that config options are compatible with each other
+func (cfg *Config) validate() error {
+	// async producer reports success before delivery, so outbox would never store events
+	if cfg.OutboxCfg.Enabled && cfg.KafkaCfg.Async && cfg.usesKafka() {
+		return errors.New("kafka async mode can't be used with outbox")
+	}
+
+	return nil
+}
+
+// usesKafka shows whether kafka sink is used
+func (cfg *Config) usesKafka() bool {
+	if len(cfg.Sinks) == 0 {
+		return true
+	}
+
+	for _, sc := range cfg.Sinks {
+		if sc.Type == sinkTypeKafka {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,48 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/keenywheels/go-spy/internal/scheduler/repository/sink"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "sync kafka with outbox", cfg: Config{OutboxCfg: OutboxConfig{Enabled: true}}},
+		{name: "async kafka without outbox", cfg: Config{KafkaCfg: KafkaConfig{Async: true}}},
+		{
+			name:    "async default kafka sink with outbox",
+			cfg:     Config{KafkaCfg: KafkaConfig{Async: true}, OutboxCfg: OutboxConfig{Enabled: true}},
+			wantErr: true,
+		},
+		{
+			name: "async kafka sink with outbox",
+			cfg: Config{
+				KafkaCfg:  KafkaConfig{Async: true},
+				OutboxCfg: OutboxConfig{Enabled: true},
+				Sinks:     []sink.Config{{Type: sink.TypeFile}, {Type: sinkTypeKafka}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "async kafka isn't used by sinks",
+			cfg: Config{
+				KafkaCfg:  KafkaConfig{Async: true},
+				OutboxCfg: OutboxConfig{Enabled: true},
+				Sinks:     []sink.Config{{Type: sink.TypeStdout}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,9 +1,28 @@
 package models
 
+// ScraperEventSchemaVersion is the version of ScraperEvent format, it's changed only
+// by incompatible changes, optional fields are added without it
+const ScraperEventSchemaVersion = "1"
+
 // ScraperEvent represents an event when the scraper gets data
 type ScraperEvent struct {
+	RunID    string `json:"run_id"`
 	SiteName string `json:"site_name"`
 	Category string `json:"category"`
 	Msg      string `json:"msg"`
 	Date     string `json:"date"`
+	// Original contains original forms of stemmed words of Msg, set if they are kept
+	Original string `json:"original,omitempty"`
+
+	// page fields are set only in documents output mode
+	URL             string   `json:"url,omitempty"`
+	FinalURL        string   `json:"final_url,omitempty"`
+	Status          int      `json:"status,omitempty"`
+	Title           string   `json:"title,omitempty"`
+	Headings        []string `json:"headings,omitempty"`
+	MetaDescription string   `json:"meta_description,omitempty"`
+	Language        string   `json:"language,omitempty"`
+	FetchedAt       string   `json:"fetched_at,omitempty"`
+	// Passages are sentence-preserving parts of Msg, set if passage size is configured
+	Passages []string `json:"passages,omitempty"`
 }
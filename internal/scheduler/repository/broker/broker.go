@@ -1,6 +1,8 @@
 package broker
 
 import (
+	"os"
+
 	"github.com/keenywheels/go-spy/internal/pkg/producer/kafka"
 )
 
@@ -9,16 +11,34 @@ type Topics struct {
 	ScraperData string
 }
 
+// IProducer represents kafka producer interface
+type IProducer interface {
+	ProduceJSON(msg kafka.Message) error
+	Close() error
+}
+
 // Broker represents broker instance
 type Broker struct {
-	topics Topics
-	kafka  *kafka.Kafka
+	topics   Topics
+	kafka    IProducer
+	hostname string
 }
 
 // New creates new broker instance
-func New(kafka *kafka.Kafka, topics Topics) *Broker {
+func New(kafka IProducer, topics Topics) *Broker {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
 	return &Broker{
-		topics: topics,
-		kafka:  kafka,
+		topics:   topics,
+		kafka:    kafka,
+		hostname: hostname,
 	}
 }
+
+// Close closes kafka producer, outstanding messages are delivered before
+func (b *Broker) Close() error {
+	return b.kafka.Close()
+}
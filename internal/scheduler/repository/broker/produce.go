@@ -1,15 +1,37 @@
 package broker
 
 import (
+	"time"
+
 	"github.com/keenywheels/go-spy/internal/pkg/producer/kafka"
 	"github.com/keenywheels/go-spy/internal/scheduler/models"
 )
 
-// SendScraperData sends scraper data to the specified topic
+// message headers
+const (
+	headerRunID         = "run_id"
+	headerSchemaVersion = "schema_version"
+	headerContentType   = "content_type"
+	headerProducer      = "producer"
+)
+
+// contentTypeJSON content type of produced messages
+const contentTypeJSON = "application/json"
+
+// SendScraperData sends scraper data to the specified topic,
+// site name is used as key to keep site's messages ordered
 func (b *Broker) SendScraperData(event models.ScraperEvent) error {
 	kafkaMsg := kafka.Message{
 		Topic: b.topics.ScraperData,
 		Value: event,
+		Key:   event.SiteName,
+		Headers: map[string]string{
+			headerRunID:         event.RunID,
+			headerSchemaVersion: models.ScraperEventSchemaVersion,
+			headerContentType:   contentTypeJSON,
+			headerProducer:      b.hostname,
+		},
+		Timestamp: time.Now(),
 	}
 
 	return b.kafka.ProduceJSON(kafkaMsg)
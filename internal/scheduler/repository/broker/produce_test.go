@@ -0,0 +1,67 @@
+package broker
+
+import (
+	"maps"
+	"reflect"
+	"testing"
+
+	"github.com/keenywheels/go-spy/internal/pkg/producer/kafka"
+	"github.com/keenywheels/go-spy/internal/scheduler/models"
+)
+
+// testProducer records produced messages
+type testProducer struct {
+	msgs []kafka.Message
+}
+
+func (p *testProducer) ProduceJSON(msg kafka.Message) error {
+	p.msgs = append(p.msgs, msg)
+	return nil
+}
+
+func (p *testProducer) Close() error {
+	return nil
+}
+
+func TestSendScraperData(t *testing.T) {
+	p := &testProducer{}
+	b := New(p, Topics{ScraperData: "scraper-data"})
+	b.hostname = "scheduler-1"
+
+	events := []models.ScraperEvent{
+		{SiteName: "habr", RunID: "run-1", Msg: "first"},
+		{SiteName: "coursera", RunID: "run-2", Msg: "second"},
+	}
+
+	for _, event := range events {
+		if err := b.SendScraperData(event); err != nil {
+			t.Fatalf("failed to send scraper data: %v", err)
+		}
+	}
+
+	if len(p.msgs) != len(events) {
+		t.Fatalf("got %d messages, want %d", len(p.msgs), len(events))
+	}
+
+	for i, event := range events {
+		msg := p.msgs[i]
+
+		if msg.Topic != "scraper-data" || msg.Key != event.SiteName || !reflect.DeepEqual(msg.Value, event) {
+			t.Fatalf("got message %+v, want event %+v with site name key in scraper-data topic", msg, event)
+		}
+
+		if msg.Timestamp.IsZero() {
+			t.Fatal("message timestamp isn't set")
+		}
+
+		want := map[string]string{
+			headerRunID:         event.RunID,
+			headerSchemaVersion: models.ScraperEventSchemaVersion,
+			headerContentType:   contentTypeJSON,
+			headerProducer:      "scheduler-1",
+		}
+		if !maps.Equal(msg.Headers, want) {
+			t.Fatalf("got headers %v, want %v", msg.Headers, want)
+		}
+	}
+}
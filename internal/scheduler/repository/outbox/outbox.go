@@ -0,0 +1,459 @@
+package outbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keenywheels/go-spy/internal/scheduler/models"
+	"github.com/keenywheels/go-spy/pkg/logger"
+)
+
+// eviction policies
+const (
+	EvictDropOldest = "drop_oldest"
+	EvictDropNewest = "drop_newest"
+)
+
+// default config values
+const (
+	defaultSegmentSize    = 16 << 20
+	defaultMaxSize        = 1 << 30
+	defaultReplayInterval = 10 * time.Second
+)
+
+// file names
+const (
+	segmentExt = ".seg"
+	cursorFile = "cursor.json"
+)
+
+// ErrFull is returned when event doesn't fit in outbox and newest events are dropped
+var ErrFull = errors.New("outbox is full")
+
+// Sender represents broker which outbox delivers events to, SendScraperData must return
+// only after event is delivered, otherwise events are lost when broker fails
+type Sender interface {
+	SendScraperData(event models.ScraperEvent) error
+}
+
+// Config contains outbox settings
+type Config struct {
+	// Dir specifies directory for segment files
+	Dir string
+	// SegmentSize specifies maximum size of single segment file in bytes
+	SegmentSize int64
+	// MaxSize specifies maximum size of all segment files in bytes
+	MaxSize int64
+	// Eviction specifies what to drop when outbox is full: drop_oldest or drop_newest
+	Eviction string
+	// ReplayInterval specifies how often stored events are replayed
+	ReplayInterval time.Duration
+}
+
+// segment represents append-only segment file
+type segment struct {
+	id    uint64
+	size  int64
+	count int
+}
+
+// cursor represents replay position in the oldest segment
+type cursor struct {
+	Segment uint64 `json:"segment"`
+	Offset  int64  `json:"offset"`
+	Line    int    `json:"line"`
+}
+
+// Outbox stores events which failed to be sent in append-only segment files
+// and replays them in order when broker recovers
+type Outbox struct {
+	cfg    Config
+	next   Sender
+	logger logger.Logger
+
+	mu       sync.Mutex
+	segments []*segment // oldest first, the last one is active
+	active   *os.File
+	cur      cursor
+	size     int64
+	depth    int
+	dropped  int
+}
+
+// New creates outbox in configured directory, restores backlog left by previous run
+func New(cfg Config, next Sender, l logger.Logger) (*Outbox, error) {
+	if cfg.SegmentSize <= 0 {
+		cfg.SegmentSize = defaultSegmentSize
+	}
+
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = defaultMaxSize
+	}
+
+	if cfg.ReplayInterval <= 0 {
+		cfg.ReplayInterval = defaultReplayInterval
+	}
+
+	switch cfg.Eviction {
+	case "":
+		cfg.Eviction = EvictDropOldest
+	case EvictDropOldest, EvictDropNewest:
+	default:
+		return nil, fmt.Errorf("unknown eviction policy: %s", cfg.Eviction)
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create outbox dir: %w", err)
+	}
+
+	o := &Outbox{
+		cfg:    cfg,
+		next:   next,
+		logger: l,
+	}
+
+	if err := o.load(); err != nil {
+		return nil, fmt.Errorf("failed to load outbox: %w", err)
+	}
+
+	return o, nil
+}
+
+// SendScraperData sends event to broker, stores it in outbox if broker fails.
+// While outbox isn't empty, events are stored to keep them ordered.
+func (o *Outbox) SendScraperData(event models.ScraperEvent) error {
+	if o.Depth() == 0 {
+		err := o.next.SendScraperData(event)
+		if err == nil {
+			return nil
+		}
+
+		o.logger.Warnf("[Outbox.SendScraperData] failed to send event, storing it in outbox: %v", err)
+	}
+
+	return o.Store(event)
+}
+
+// Store appends event to outbox
+func (o *Outbox) Store(event models.ScraperEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	data = append(data, '\n')
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := o.evict(int64(len(data))); err != nil {
+		return err
+	}
+
+	last := o.segments[len(o.segments)-1]
+	if last.size > 0 && last.size+int64(len(data)) > o.cfg.SegmentSize {
+		if err := o.rotate(); err != nil {
+			return err
+		}
+
+		last = o.segments[len(o.segments)-1]
+	}
+
+	if _, err := o.active.Write(data); err != nil {
+		return fmt.Errorf("failed to write event to outbox: %w", err)
+	}
+
+	if err := o.active.Sync(); err != nil {
+		return fmt.Errorf("failed to sync outbox segment: %w", err)
+	}
+
+	last.size += int64(len(data))
+	last.count++
+	o.size += int64(len(data))
+	o.depth++
+
+	return nil
+}
+
+// Depth returns number of events waiting for replay
+func (o *Outbox) Depth() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.depth
+}
+
+// Size returns size of outbox files in bytes
+func (o *Outbox) Size() int64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.size
+}
+
+// Dropped returns number of events dropped by eviction policy
+func (o *Outbox) Dropped() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.dropped
+}
+
+// Close closes active segment file
+func (o *Outbox) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.active.Close()
+}
+
+// load restores segments and cursor from outbox directory
+func (o *Outbox) load() error {
+	entries, err := os.ReadDir(o.cfg.Dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		seg, err := o.scanSegment(id)
+		if err != nil {
+			return err
+		}
+
+		o.segments = append(o.segments, seg)
+		o.size += seg.size
+	}
+
+	sort.Slice(o.segments, func(i, j int) bool {
+		return o.segments[i].id < o.segments[j].id
+	})
+
+	// restore cursor, segments before it are already replayed
+	if data, err := os.ReadFile(filepath.Join(o.cfg.Dir, cursorFile)); err == nil {
+		if err := json.Unmarshal(data, &o.cur); err != nil {
+			o.logger.Errorf("[Outbox.load] failed to parse cursor, replaying from start: %v", err)
+			o.cur = cursor{}
+		}
+	}
+
+	for len(o.segments) > 0 && o.segments[0].id < o.cur.Segment {
+		if err := o.removeSegment(); err != nil {
+			return err
+		}
+	}
+
+	if len(o.segments) == 0 {
+		o.segments = append(o.segments, &segment{id: 1})
+	}
+
+	if o.segments[0].id != o.cur.Segment {
+		o.cur = cursor{Segment: o.segments[0].id}
+	}
+
+	for _, seg := range o.segments {
+		o.depth += seg.count
+	}
+
+	o.depth -= o.cur.Line
+
+	last := o.segments[len(o.segments)-1]
+
+	o.active, err = os.OpenFile(o.segmentPath(last.id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open segment: %w", err)
+	}
+
+	if o.depth > 0 {
+		o.logger.Infof("[Outbox.load] restored %d events from outbox", o.depth)
+	}
+
+	return nil
+}
+
+// scanSegment counts complete events in the segment file
+func (o *Outbox) scanSegment(id uint64) (*segment, error) {
+	f, err := os.Open(o.segmentPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open segment: %w", err)
+	}
+	defer f.Close()
+
+	seg := &segment{id: id}
+	r := bufio.NewReader(f)
+
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// partially written event is ignored
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read segment: %w", err)
+		}
+
+		seg.size += int64(len(line))
+		seg.count++
+	}
+
+	if err := os.Truncate(o.segmentPath(id), seg.size); err != nil {
+		return nil, fmt.Errorf("failed to truncate segment: %w", err)
+	}
+
+	return seg, nil
+}
+
+// evict frees space for n bytes according to eviction policy
+func (o *Outbox) evict(n int64) error {
+	for o.size+n > o.cfg.MaxSize {
+		if o.cfg.Eviction == EvictDropNewest || o.size == 0 {
+			o.dropped++
+			return ErrFull
+		}
+
+		// active segment is rotated to be removed
+		if len(o.segments) == 1 {
+			if err := o.rotate(); err != nil {
+				return err
+			}
+		}
+
+		lost := o.segments[0].count - o.cur.Line
+		if err := o.removeSegment(); err != nil {
+			return err
+		}
+
+		o.depth -= lost
+		o.dropped += lost
+		o.cur = cursor{Segment: o.segments[0].id}
+
+		o.logger.Warnf("[Outbox.evict] outbox is full, dropped %d oldest events", lost)
+
+		if err := o.saveCursor(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rotate closes active segment and creates a new one
+func (o *Outbox) rotate() error {
+	if err := o.active.Sync(); err != nil {
+		return fmt.Errorf("failed to sync segment: %w", err)
+	}
+
+	if err := o.active.Close(); err != nil {
+		return fmt.Errorf("failed to close segment: %w", err)
+	}
+
+	seg := &segment{id: o.segments[len(o.segments)-1].id + 1}
+
+	f, err := os.OpenFile(o.segmentPath(seg.id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create segment: %w", err)
+	}
+
+	o.active = f
+	o.segments = append(o.segments, seg)
+
+	return syncDir(o.cfg.Dir)
+}
+
+// removeSegment removes the oldest segment file
+func (o *Outbox) removeSegment() error {
+	seg := o.segments[0]
+
+	if err := os.Remove(o.segmentPath(seg.id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove segment: %w", err)
+	}
+
+	o.size -= seg.size
+	o.segments = o.segments[1:]
+
+	return nil
+}
+
+// saveCursor persists replay position, segments are synced before,
+// so cursor never points past data which isn't on disk
+func (o *Outbox) saveCursor() error {
+	if err := o.active.Sync(); err != nil {
+		return fmt.Errorf("failed to sync segment: %w", err)
+	}
+
+	data, err := json.Marshal(o.cur)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cursor: %w", err)
+	}
+
+	tmp := filepath.Join(o.cfg.Dir, cursorFile+".tmp")
+	if err := writeFileSync(tmp, data); err != nil {
+		return fmt.Errorf("failed to write cursor: %w", err)
+	}
+
+	if err := os.Rename(tmp, filepath.Join(o.cfg.Dir, cursorFile)); err != nil {
+		return fmt.Errorf("failed to rename cursor: %w", err)
+	}
+
+	return syncDir(o.cfg.Dir)
+}
+
+// writeFileSync writes data to file and flushes it to disk
+func writeFileSync(name string, data []byte) error {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// syncDir flushes directory entries to disk, so renames and new files survive a crash
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open dir: %w", err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync dir: %w", err)
+	}
+
+	return nil
+}
+
+// segmentPath returns path of the segment file
+func (o *Outbox) segmentPath(id uint64) string {
+	return filepath.Join(o.cfg.Dir, fmt.Sprintf("%020d%s", id, segmentExt))
+}
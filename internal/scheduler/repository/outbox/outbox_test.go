@@ -0,0 +1,266 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/keenywheels/go-spy/internal/scheduler/models"
+	"github.com/keenywheels/go-spy/pkg/logger/zap"
+)
+
+// errUnavailable is returned by sender while broker is down
+var errUnavailable = errors.New("broker is unavailable")
+
+// fakeSender records delivered events, fails while down or after limit is reached
+type fakeSender struct {
+	down  bool
+	limit int
+	sent  []models.ScraperEvent
+}
+
+func (s *fakeSender) SendScraperData(event models.ScraperEvent) error {
+	if s.down || (s.limit > 0 && len(s.sent) >= s.limit) {
+		return errUnavailable
+	}
+
+	s.sent = append(s.sent, event)
+	return nil
+}
+
+func newTestOutbox(t *testing.T, cfg Config, next Sender) *Outbox {
+	t.Helper()
+
+	l := zap.New(zap.LogPath(filepath.Join(t.TempDir(), "app.log")))
+
+	o, err := New(cfg, next, l)
+	if err != nil {
+		t.Fatalf("failed to create outbox: %v", err)
+	}
+
+	return o
+}
+
+func event(i int) models.ScraperEvent {
+	return models.ScraperEvent{SiteName: "site", Msg: strconv.Itoa(i)}
+}
+
+func checkSent(t *testing.T, sent []models.ScraperEvent, from, to int) {
+	t.Helper()
+
+	if len(sent) != to-from {
+		t.Fatalf("sent %d events, want %d", len(sent), to-from)
+	}
+
+	for i, e := range sent {
+		if want := strconv.Itoa(from + i); e.Msg != want {
+			t.Fatalf("event %d is %q, want %q", i, e.Msg, want)
+		}
+	}
+}
+
+func TestOutboxStoresWhileBrokerIsDown(t *testing.T) {
+	sender := &fakeSender{down: true}
+	o := newTestOutbox(t, Config{Dir: t.TempDir()}, sender)
+	defer o.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := o.SendScraperData(event(i)); err != nil {
+			t.Fatalf("failed to send event: %v", err)
+		}
+	}
+
+	// broker is back, but events are stored while outbox isn't empty to keep order
+	sender.down = false
+	if err := o.SendScraperData(event(3)); err != nil {
+		t.Fatalf("failed to send event: %v", err)
+	}
+
+	if len(sender.sent) != 0 || o.Depth() != 4 {
+		t.Fatalf("sent=%d depth=%d, want sent=0 depth=4", len(sender.sent), o.Depth())
+	}
+
+	n, err := o.replay(context.Background())
+	if err != nil {
+		t.Fatalf("failed to replay: %v", err)
+	}
+
+	if n != 4 || o.Depth() != 0 {
+		t.Fatalf("replayed=%d depth=%d, want replayed=4 depth=0", n, o.Depth())
+	}
+
+	checkSent(t, sender.sent, 0, 4)
+
+	// outbox is empty, event is sent directly
+	if err := o.SendScraperData(event(4)); err != nil {
+		t.Fatalf("failed to send event: %v", err)
+	}
+
+	checkSent(t, sender.sent, 0, 5)
+}
+
+func TestOutboxRestart(t *testing.T) {
+	tests := []struct {
+		name        string
+		segmentSize int64
+		events      int
+		replayed    int
+	}{
+		{name: "single segment", segmentSize: 1 << 20, events: 10, replayed: 4},
+		{name: "many segments", segmentSize: 64, events: 10, replayed: 4},
+		{name: "nothing replayed", segmentSize: 64, events: 5, replayed: 0},
+		{name: "everything replayed", segmentSize: 64, events: 5, replayed: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Dir: t.TempDir(), SegmentSize: tt.segmentSize}
+
+			sender := &fakeSender{limit: tt.replayed, down: tt.replayed == 0}
+			o := newTestOutbox(t, cfg, sender)
+
+			for i := 0; i < tt.events; i++ {
+				if err := o.Store(event(i)); err != nil {
+					t.Fatalf("failed to store event: %v", err)
+				}
+			}
+
+			if _, err := o.replay(context.Background()); err != nil && !errors.Is(err, errUnavailable) {
+				t.Fatalf("failed to replay: %v", err)
+			}
+
+			checkSent(t, sender.sent, 0, tt.replayed)
+
+			if err := o.Close(); err != nil {
+				t.Fatalf("failed to close outbox: %v", err)
+			}
+
+			// restarted outbox continues from the saved cursor
+			sender = &fakeSender{}
+			o = newTestOutbox(t, cfg, sender)
+			defer o.Close()
+
+			if o.Depth() != tt.events-tt.replayed {
+				t.Fatalf("restored depth=%d, want %d", o.Depth(), tt.events-tt.replayed)
+			}
+
+			if _, err := o.replay(context.Background()); err != nil {
+				t.Fatalf("failed to replay: %v", err)
+			}
+
+			checkSent(t, sender.sent, tt.replayed, tt.events)
+		})
+	}
+}
+
+func TestOutboxIgnoresPartialEvent(t *testing.T) {
+	cfg := Config{Dir: t.TempDir()}
+
+	o := newTestOutbox(t, cfg, &fakeSender{})
+	for i := 0; i < 2; i++ {
+		if err := o.Store(event(i)); err != nil {
+			t.Fatalf("failed to store event: %v", err)
+		}
+	}
+
+	// simulate crash in the middle of write
+	if _, err := o.active.WriteString(`{"site_name":"si`); err != nil {
+		t.Fatalf("failed to write partial event: %v", err)
+	}
+
+	o.Close()
+
+	sender := &fakeSender{}
+	o = newTestOutbox(t, cfg, sender)
+	defer o.Close()
+
+	if err := o.Store(event(2)); err != nil {
+		t.Fatalf("failed to store event: %v", err)
+	}
+
+	if _, err := o.replay(context.Background()); err != nil {
+		t.Fatalf("failed to replay: %v", err)
+	}
+
+	checkSent(t, sender.sent, 0, 3)
+}
+
+func TestOutboxEviction(t *testing.T) {
+	// size of stored single digit event with new line
+	size := int64(len(`{"run_id":"","site_name":"site","category":"","msg":"0","date":""}`) + 1)
+
+	tests := []struct {
+		name     string
+		eviction string
+		from, to int
+		dropped  int
+	}{
+		{name: "drop oldest", eviction: EvictDropOldest, from: 4, to: 10, dropped: 4},
+		{name: "drop newest", eviction: EvictDropNewest, from: 0, to: 6, dropped: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// two events per segment, six events fit in outbox
+			o := newTestOutbox(t, Config{
+				Dir:         t.TempDir(),
+				SegmentSize: 2 * size,
+				MaxSize:     6 * size,
+				Eviction:    tt.eviction,
+			}, &fakeSender{})
+			defer o.Close()
+
+			for i := 0; i < 10; i++ {
+				err := o.Store(event(i))
+				if err != nil && !errors.Is(err, ErrFull) {
+					t.Fatalf("failed to store event: %v", err)
+				}
+			}
+
+			if o.Dropped() != tt.dropped {
+				t.Fatalf("dropped %d events, want %d", o.Dropped(), tt.dropped)
+			}
+
+			sender := &fakeSender{}
+			o.next = sender
+
+			if _, err := o.replay(context.Background()); err != nil {
+				t.Fatalf("failed to replay: %v", err)
+			}
+
+			checkSent(t, sender.sent, tt.from, tt.to)
+		})
+	}
+}
+
+func TestOutboxRemovesReplayedSegments(t *testing.T) {
+	dir := t.TempDir()
+	o := newTestOutbox(t, Config{Dir: dir, SegmentSize: 64}, &fakeSender{})
+	defer o.Close()
+
+	for i := 0; i < 10; i++ {
+		if err := o.Store(event(i)); err != nil {
+			t.Fatalf("failed to store event: %v", err)
+		}
+	}
+
+	if _, err := o.replay(context.Background()); err != nil {
+		t.Fatalf("failed to replay: %v", err)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	if err != nil {
+		t.Fatalf("failed to list segments: %v", err)
+	}
+
+	if len(matches) != 1 {
+		t.Fatalf("%d segments left, want 1", len(matches))
+	}
+
+	if info, err := os.Stat(matches[0]); err != nil || info.Size() != 0 {
+		t.Fatalf("the last segment isn't truncated: %v", err)
+	}
+}
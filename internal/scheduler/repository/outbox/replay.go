@@ -0,0 +1,156 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/keenywheels/go-spy/internal/scheduler/models"
+)
+
+// Run replays stored events every replay interval until context is done
+func (o *Outbox) Run(ctx context.Context) error {
+	ticker := time.NewTicker(o.cfg.ReplayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if o.Depth() == 0 {
+				continue
+			}
+
+			n, err := o.replay(ctx)
+			if err != nil {
+				o.logger.Errorf("[Outbox.Run] replay stopped after %d events, %d left: %v", n, o.Depth(), err)
+				continue
+			}
+
+			o.logger.Infof("[Outbox.Run] replayed %d events", n)
+		}
+	}
+}
+
+// replay sends stored events in order until outbox is empty or broker fails
+func (o *Outbox) replay(ctx context.Context) (int, error) {
+	n := 0
+
+	for ctx.Err() == nil {
+		event, pos, ok, err := o.peek()
+		if err != nil {
+			return n, err
+		}
+
+		if !ok {
+			return n, nil
+		}
+
+		if event != nil {
+			if err := o.next.SendScraperData(*event); err != nil {
+				return n, err
+			}
+
+			n++
+		}
+
+		if err := o.advance(pos); err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// peek reads event at cursor, returns cursor which points to the next event.
+// Nil event is returned for corrupted records which should be skipped.
+func (o *Outbox) peek() (*models.ScraperEvent, cursor, bool, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for {
+		seg := o.segments[0]
+
+		if o.cur.Line < seg.count {
+			break
+		}
+
+		// the active segment is drained, outbox is empty
+		if len(o.segments) == 1 {
+			return nil, o.cur, false, nil
+		}
+
+		// drained segment isn't needed anymore
+		if err := o.removeSegment(); err != nil {
+			return nil, o.cur, false, err
+		}
+
+		o.cur = cursor{Segment: o.segments[0].id}
+		if err := o.saveCursor(); err != nil {
+			return nil, o.cur, false, err
+		}
+	}
+
+	f, err := os.Open(o.segmentPath(o.cur.Segment))
+	if err != nil {
+		return nil, o.cur, false, fmt.Errorf("failed to open segment: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(o.cur.Offset, io.SeekStart); err != nil {
+		return nil, o.cur, false, fmt.Errorf("failed to seek segment: %w", err)
+	}
+
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, o.cur, false, fmt.Errorf("failed to read segment: %w", err)
+	}
+
+	pos := cursor{
+		Segment: o.cur.Segment,
+		Offset:  o.cur.Offset + int64(len(line)),
+		Line:    o.cur.Line + 1,
+	}
+
+	var event models.ScraperEvent
+	if err := json.Unmarshal(line, &event); err != nil {
+		o.logger.Errorf("[Outbox.peek] skipping corrupted event in segment %d: %v", o.cur.Segment, err)
+		return nil, pos, true, nil
+	}
+
+	return &event, pos, true, nil
+}
+
+// advance moves cursor to the specified position if outbox wasn't evicted meanwhile
+func (o *Outbox) advance(pos cursor) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.cur.Segment != pos.Segment || o.cur.Line != pos.Line-1 {
+		return nil
+	}
+
+	o.cur = pos
+	o.depth--
+
+	// everything is replayed, so the only segment can be reused
+	if o.depth == 0 && len(o.segments) == 1 {
+		if err := o.active.Truncate(0); err != nil {
+			return fmt.Errorf("failed to truncate segment: %w", err)
+		}
+
+		seg := o.segments[0]
+		o.size -= seg.size
+		seg.size = 0
+		seg.count = 0
+		o.cur = cursor{Segment: seg.id}
+	}
+
+	return o.saveCursor()
+}
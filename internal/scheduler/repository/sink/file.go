@@ -0,0 +1,88 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/keenywheels/go-spy/internal/scheduler/models"
+	"github.com/keenywheels/go-spy/pkg/logger"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// default file sink values
+const (
+	defaultFileMaxSize    = 100
+	defaultFileMaxBackups = 10
+)
+
+// FileConfig contains config for rotating JSONL file sink
+type FileConfig struct {
+	// Path specifies path of the output file
+	Path string `mapstructure:"path"`
+	// MaxSize specifies max size of the file in MB before rotation
+	MaxSize int `mapstructure:"max_size"`
+	// MaxBackups specifies max number of rotated files
+	MaxBackups int `mapstructure:"max_backups"`
+	// MaxAge specifies max age of rotated files in days
+	MaxAge int `mapstructure:"max_age"`
+	// Compress shows whether rotated files are compressed
+	Compress bool `mapstructure:"compress"`
+}
+
+// fileSink writes events as JSON lines to rotating file
+type fileSink struct {
+	mu sync.Mutex
+	w  *lumberjack.Logger
+}
+
+// newFileSink creates file sink
+func newFileSink(_ context.Context, cfg Config, _ logger.Logger) (Sink, error) {
+	if cfg.File.Path == "" {
+		return nil, errors.New("file path is not specified")
+	}
+
+	maxSize := defaultFileMaxSize
+	if cfg.File.MaxSize != 0 {
+		maxSize = cfg.File.MaxSize
+	}
+
+	maxBackups := defaultFileMaxBackups
+	if cfg.File.MaxBackups != 0 {
+		maxBackups = cfg.File.MaxBackups
+	}
+
+	return &fileSink{
+		w: &lumberjack.Logger{
+			Filename:   cfg.File.Path,
+			MaxSize:    maxSize,
+			MaxBackups: maxBackups,
+			MaxAge:     cfg.File.MaxAge,
+			Compress:   cfg.File.Compress,
+		},
+	}, nil
+}
+
+// SendScraperData writes event to file
+func (s *fileSink) SendScraperData(event models.ScraperEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write event to file: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes file
+func (s *fileSink) Close() error {
+	return s.w.Close()
+}
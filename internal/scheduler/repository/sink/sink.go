@@ -0,0 +1,160 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/keenywheels/go-spy/internal/scheduler/models"
+	"github.com/keenywheels/go-spy/pkg/logger"
+)
+
+// builtin sink types
+const (
+	TypeFile    = "file"
+	TypeStdout  = "stdout"
+	TypeWebhook = "webhook"
+)
+
+// Sink represents output for scraper events
+type Sink interface {
+	SendScraperData(event models.ScraperEvent) error
+	Close() error
+}
+
+// Config contains sink config, only settings of the selected type are used
+type Config struct {
+	// Name identifies sink, sink type is used if empty
+	Name    string        `mapstructure:"name"`
+	Type    string        `mapstructure:"type"`
+	File    FileConfig    `mapstructure:"file"`
+	Webhook WebhookConfig `mapstructure:"webhook"`
+}
+
+// ID returns unique sink name
+func (cfg Config) ID() string {
+	if cfg.Name != "" {
+		return cfg.Name
+	}
+
+	return cfg.Type
+}
+
+// Factory creates sink from config, ctx is done when app is shutting down
+type Factory func(ctx context.Context, cfg Config, l logger.Logger) (Sink, error)
+
+// Wrapper wraps created sink, e.g. with outbox, wrapped sink closes the original one
+type Wrapper func(cfg Config, s Sink) (Sink, error)
+
+// Registry contains available sink factories
+type Registry struct {
+	ctx       context.Context
+	factories map[string]Factory
+}
+
+// NewRegistry creates registry with builtin sinks, ctx is passed to created sinks
+func NewRegistry(ctx context.Context) *Registry {
+	return &Registry{
+		ctx: ctx,
+		factories: map[string]Factory{
+			TypeFile:    newFileSink,
+			TypeStdout:  newStdoutSink,
+			TypeWebhook: newWebhookSink,
+		},
+	}
+}
+
+// Register adds sink factory, factory registered with the same type is replaced
+func (r *Registry) Register(sinkType string, f Factory) {
+	r.factories[sinkType] = f
+}
+
+// Build creates sinks from configs, every sink is wrapped if wrap isn't nil.
+// Multiple sinks are combined with fan-out.
+func (r *Registry) Build(cfgs []Config, l logger.Logger, wrap Wrapper) (Sink, error) {
+	if len(cfgs) == 0 {
+		return nil, errors.New("no sinks configured")
+	}
+
+	ids := make(map[string]struct{}, len(cfgs))
+	for _, cfg := range cfgs {
+		if _, ok := ids[cfg.ID()]; ok {
+			return nil, fmt.Errorf("duplicate sink name: %s", cfg.ID())
+		}
+
+		ids[cfg.ID()] = struct{}{}
+	}
+
+	sinks := make([]Sink, 0, len(cfgs))
+
+	for _, cfg := range cfgs {
+		f, ok := r.factories[cfg.Type]
+		if !ok {
+			closeAll(sinks)
+			return nil, fmt.Errorf("unknown sink type: %s", cfg.Type)
+		}
+
+		s, err := f(r.ctx, cfg, l)
+		if err != nil {
+			closeAll(sinks)
+			return nil, fmt.Errorf("failed to create %s sink: %w", cfg.ID(), err)
+		}
+
+		if wrap != nil {
+			ws, err := wrap(cfg, s)
+			if err != nil {
+				s.Close()
+				closeAll(sinks)
+				return nil, fmt.Errorf("failed to wrap %s sink: %w", cfg.ID(), err)
+			}
+
+			s = ws
+		}
+
+		sinks = append(sinks, s)
+	}
+
+	if len(sinks) == 1 {
+		return sinks[0], nil
+	}
+
+	return &FanOut{sinks: sinks}, nil
+}
+
+// FanOut sends events to multiple sinks. Failed sinks aren't retried by fan-out,
+// every sink should be wrapped with its own outbox, so retries don't duplicate
+// events in sinks which succeeded.
+type FanOut struct {
+	sinks []Sink
+}
+
+// SendScraperData sends event to every sink, returns joined errors of failed sinks
+func (f *FanOut) SendScraperData(event models.ScraperEvent) error {
+	var errs []error
+
+	for _, s := range f.sinks {
+		if err := s.SendScraperData(event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close closes all sinks
+func (f *FanOut) Close() error {
+	return closeAll(f.sinks)
+}
+
+// closeAll closes sinks and returns joined errors
+func closeAll(sinks []Sink) error {
+	var errs []error
+
+	for _, s := range sinks {
+		if err := s.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
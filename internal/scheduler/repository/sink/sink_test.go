@@ -0,0 +1,210 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/keenywheels/go-spy/internal/scheduler/models"
+	"github.com/keenywheels/go-spy/pkg/logger"
+	"github.com/keenywheels/go-spy/pkg/logger/zap"
+)
+
+func testLogger(t *testing.T) logger.Logger {
+	t.Helper()
+
+	return zap.New(zap.LogPath(filepath.Join(t.TempDir(), "app.log")))
+}
+
+func TestWebhookRetries(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+
+	tests := []struct {
+		name       string
+		maxRetries *int
+		status     int
+		requests   int32
+	}{
+		{name: "default retries", maxRetries: nil, status: http.StatusServiceUnavailable, requests: 4},
+		{name: "retries disabled", maxRetries: intPtr(0), status: http.StatusServiceUnavailable, requests: 1},
+		{name: "custom retries", maxRetries: intPtr(1), status: http.StatusTooManyRequests, requests: 2},
+		{name: "not retried status", maxRetries: intPtr(3), status: http.StatusBadRequest, requests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				requests.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			s, err := newWebhookSink(context.Background(), Config{
+				Type: TypeWebhook,
+				Webhook: WebhookConfig{
+					URL:          srv.URL,
+					MaxRetries:   tt.maxRetries,
+					RetryBackoff: time.Millisecond,
+				},
+			}, testLogger(t))
+			if err != nil {
+				t.Fatalf("failed to create webhook sink: %v", err)
+			}
+			defer s.Close()
+
+			if err := s.SendScraperData(models.ScraperEvent{SiteName: "site"}); err == nil {
+				t.Fatal("expected error")
+			}
+
+			if got := requests.Load(); got != tt.requests {
+				t.Fatalf("got %d requests, want %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestWebhookRetryIsCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s, err := newWebhookSink(ctx, Config{
+		Type:    TypeWebhook,
+		Webhook: WebhookConfig{URL: srv.URL, RetryBackoff: time.Hour},
+	}, testLogger(t))
+	if err != nil {
+		t.Fatalf("failed to create webhook sink: %v", err)
+	}
+	defer s.Close()
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.SendScraperData(models.ScraperEvent{SiteName: "site"})
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("got error %v, want context canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("retry isn't interrupted by context")
+	}
+}
+
+func TestWebhookSendsAfterShutdown(t *testing.T) {
+	var requests atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s, err := newWebhookSink(ctx, Config{
+		Type:    TypeWebhook,
+		Webhook: WebhookConfig{URL: srv.URL},
+	}, testLogger(t))
+	if err != nil {
+		t.Fatalf("failed to create webhook sink: %v", err)
+	}
+	defer s.Close()
+
+	// events flushed on close are sent after app context is canceled
+	if err := s.SendScraperData(models.ScraperEvent{SiteName: "site"}); err != nil {
+		t.Fatalf("failed to send event: %v", err)
+	}
+
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("got %d requests, want 1", got)
+	}
+}
+
+// fakeSink records events, fails if err is set
+type fakeSink struct {
+	err    error
+	sent   int
+	closed bool
+}
+
+func (s *fakeSink) SendScraperData(_ models.ScraperEvent) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.sent++
+	return nil
+}
+
+func (s *fakeSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestRegistryBuild(t *testing.T) {
+	failed := errors.New("sink is down")
+	sinks := map[string]*fakeSink{
+		"ok":   {},
+		"down": {err: failed},
+	}
+
+	r := NewRegistry(context.Background())
+	r.Register("fake", func(_ context.Context, cfg Config, _ logger.Logger) (Sink, error) {
+		return sinks[cfg.ID()], nil
+	})
+
+	t.Run("duplicate names", func(t *testing.T) {
+		_, err := r.Build([]Config{{Type: "fake", Name: "ok"}, {Type: "fake", Name: "ok"}}, testLogger(t), nil)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("every sink is wrapped", func(t *testing.T) {
+		wrapped := make(map[string]bool)
+
+		out, err := r.Build([]Config{{Type: "fake", Name: "ok"}, {Type: "fake", Name: "down"}}, testLogger(t),
+			func(cfg Config, s Sink) (Sink, error) {
+				wrapped[cfg.ID()] = true
+				return s, nil
+			})
+		if err != nil {
+			t.Fatalf("failed to build sinks: %v", err)
+		}
+
+		if !wrapped["ok"] || !wrapped["down"] {
+			t.Fatalf("not all sinks are wrapped: %v", wrapped)
+		}
+
+		if err := out.SendScraperData(models.ScraperEvent{}); !errors.Is(err, failed) {
+			t.Fatalf("got error %v, want %v", err, failed)
+		}
+
+		if sinks["ok"].sent != 1 {
+			t.Fatalf("healthy sink got %d events, want 1", sinks["ok"].sent)
+		}
+
+		if err := out.Close(); err != nil {
+			t.Fatalf("failed to close sinks: %v", err)
+		}
+
+		if !sinks["ok"].closed || !sinks["down"].closed {
+			t.Fatal("not all sinks are closed")
+		}
+	})
+}
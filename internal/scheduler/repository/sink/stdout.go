@@ -0,0 +1,48 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/keenywheels/go-spy/internal/scheduler/models"
+	"github.com/keenywheels/go-spy/pkg/logger"
+)
+
+// stdoutSink writes events as JSON lines to stdout
+type stdoutSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// newStdoutSink creates stdout sink
+func newStdoutSink(_ context.Context, _ Config, _ logger.Logger) (Sink, error) {
+	return &stdoutSink{
+		w: os.Stdout,
+	}, nil
+}
+
+// SendScraperData writes event to stdout
+func (s *stdoutSink) SendScraperData(event models.ScraperEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write event to stdout: %w", err)
+	}
+
+	return nil
+}
+
+// Close does nothing, stdout stays open
+func (s *stdoutSink) Close() error {
+	return nil
+}
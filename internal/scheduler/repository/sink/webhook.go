@@ -0,0 +1,173 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/keenywheels/go-spy/internal/scheduler/models"
+	"github.com/keenywheels/go-spy/pkg/logger"
+)
+
+// default webhook sink values
+const (
+	defaultWebhookTimeout      = 10 * time.Second
+	defaultWebhookMaxRetries   = 3
+	defaultWebhookRetryBackoff = time.Second
+)
+
+// signatureHeader contains HMAC-SHA256 signature of the request body
+const signatureHeader = "X-Signature-256"
+
+// WebhookConfig contains config for HTTP webhook sink
+type WebhookConfig struct {
+	// URL specifies webhook url
+	URL string `mapstructure:"url"`
+	// Secret specifies key used to sign request body, body isn't signed if empty
+	Secret string `mapstructure:"secret"`
+	// Headers specifies additional request headers
+	Headers map[string]string `mapstructure:"headers"`
+	// Timeout specifies request timeout
+	Timeout time.Duration `mapstructure:"timeout"`
+	// MaxRetries specifies number of retries for failed requests, 0 disables retries,
+	// default value is used if it isn't set
+	MaxRetries *int `mapstructure:"max_retries"`
+	// RetryBackoff specifies initial delay between retries, it's doubled after each retry
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+}
+
+// webhookSink posts events to HTTP endpoint
+type webhookSink struct {
+	ctx     context.Context
+	cfg     WebhookConfig
+	retries int
+	client  *http.Client
+	logger  logger.Logger
+}
+
+// newWebhookSink creates webhook sink
+func newWebhookSink(ctx context.Context, cfg Config, l logger.Logger) (Sink, error) {
+	whCfg := cfg.Webhook
+
+	if whCfg.URL == "" {
+		return nil, errors.New("webhook url is not specified")
+	}
+
+	if whCfg.Timeout == 0 {
+		whCfg.Timeout = defaultWebhookTimeout
+	}
+
+	retries := defaultWebhookMaxRetries
+	if whCfg.MaxRetries != nil {
+		retries = max(0, *whCfg.MaxRetries)
+	}
+
+	if whCfg.RetryBackoff == 0 {
+		whCfg.RetryBackoff = defaultWebhookRetryBackoff
+	}
+
+	return &webhookSink{
+		ctx:     ctx,
+		cfg:     whCfg,
+		retries: retries,
+		client:  &http.Client{Timeout: whCfg.Timeout},
+		logger:  l,
+	}, nil
+}
+
+// SendScraperData posts event to webhook, retries on network errors and 5xx/429 responses
+func (s *webhookSink) SendScraperData(event models.ScraperEvent) error {
+	op := "webhookSink.SendScraperData"
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	backoff := s.cfg.RetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(body)
+		if err == nil {
+			return nil
+		}
+
+		if !retry || attempt >= s.retries {
+			return fmt.Errorf("failed to send event to webhook: %w", err)
+		}
+
+		s.logger.Warnf("[%s] attempt %d failed, retrying in %s: %v", op, attempt+1, backoff, err)
+
+		// shutdown interrupts waiting, so event can be stored in outbox
+		timer := time.NewTimer(backoff)
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("failed to send event to webhook: %w", errors.Join(err, s.ctx.Err()))
+		case <-timer.C:
+		}
+
+		backoff *= 2
+	}
+}
+
+// post sends single request, returns whether failed request can be retried.
+// Request isn't canceled on shutdown, so events flushed on close are delivered,
+// it's limited by its own timeout instead.
+func (s *webhookSink) post(body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(s.ctx), s.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	if s.cfg.Secret != "" {
+		req.Header.Set(signatureHeader, "sha256="+sign(body, s.cfg.Secret))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return true, fmt.Errorf("got status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("got status %d", resp.StatusCode)
+	}
+}
+
+// Close closes idle connections
+func (s *webhookSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// sign returns hex encoded HMAC-SHA256 of the body
+func sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -6,15 +6,43 @@ import (
 	"github.com/go-co-op/gocron/v2"
 )
 
-// initJobs initializes the scheduled job based on the cron pattern
+// initJobs initializes scheduled jobs, sites with the same cron pattern share a job
 func (s *Service) initJobs() error {
-	// register job
-	_, err := s.scheduler.NewJob(
-		gocron.CronJob(s.cronPattern, false),
-		gocron.NewTask(s.ScrapeTask),
-	)
-	if err != nil {
-		return fmt.Errorf("failed to init job: %w", err)
+	// group sites by cron pattern keeping config order
+	patterns := make([]string, 0, 1)
+	sitesByPattern := make(map[string][]Site)
+
+	for _, site := range s.sites {
+		pattern := site.cronPattern(s.cfg.CronPattern)
+
+		if _, ok := sitesByPattern[pattern]; !ok {
+			patterns = append(patterns, pattern)
+		}
+
+		sitesByPattern[pattern] = append(sitesByPattern[pattern], site)
+	}
+
+	// overlapping runs are skipped or queued
+	var limitMode gocron.LimitMode = gocron.LimitModeReschedule
+	if s.cfg.OverlapMode == OverlapQueue {
+		limitMode = gocron.LimitModeWait
+	}
+
+	// register jobs
+	for _, pattern := range patterns {
+		sites := sitesByPattern[pattern]
+
+		_, err := s.scheduler.NewJob(
+			gocron.CronJob(pattern, false),
+			gocron.NewTask(s.ScrapeTask, sites),
+			gocron.WithName(pattern),
+			gocron.WithSingletonMode(limitMode),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to init job with cron pattern %s: %w", pattern, err)
+		}
+
+		s.logger.Infof("[Service.initJobs] registered job with cron pattern %s for %d sites", pattern, len(sites))
 	}
 
 	return nil
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-co-op/gocron/v2"
 	"github.com/keenywheels/go-spy/internal/pkg/scraper"
@@ -15,17 +16,44 @@ type IBroker interface {
 	SendScraperData(event models.ScraperEvent) error
 }
 
+// overlap modes define what happens when job is triggered while its previous run is still going
+const (
+	// OverlapSkip skips the new run
+	OverlapSkip = "skip"
+	// OverlapQueue starts the new run after the previous one is finished
+	OverlapQueue = "queue"
+)
+
+// Config contains scheduling settings
+type Config struct {
+	// CronPattern specifies default cron pattern for sites
+	CronPattern string
+	// WorkersCount specifies number of sites scraped concurrently, shared by all jobs
+	WorkersCount int
+	// OverlapMode specifies what to do with overlapping runs: skip or queue
+	OverlapMode string
+	// MaxRunDuration specifies maximum duration of the whole run, unlimited if zero
+	MaxRunDuration time.Duration
+	// SiteTimeout specifies default maximum duration of site scraping, unlimited if zero
+	SiteTimeout time.Duration
+	// ShutdownTimeout specifies how long running jobs are awaited on shutdown
+	ShutdownTimeout time.Duration
+}
+
 // Service represent service layer of the application
 type Service struct {
-	cronPattern  string
-	workersCount int
-	scheduler    gocron.Scheduler
+	cfg       Config
+	scheduler gocron.Scheduler
 
 	sites []Site
 
+	// workers limits number of sites scraped concurrently by all jobs
+	workers chan struct{}
+
 	ctx        context.Context
 	logger     logger.Logger
 	scraperCfg *scraper.Config
+	robots     *scraper.RobotsCache
 
 	broker IBroker
 }
@@ -35,25 +63,42 @@ func New(
 	ctx context.Context,
 	logger logger.Logger,
 	scraperCfg *scraper.Config,
-	cronPattern string,
-	workersCount int,
+	cfg Config,
 	sites []Site,
 	broker IBroker,
 ) (*Service, error) {
-	scheduler, err := gocron.NewScheduler()
+	opts := []gocron.SchedulerOption{}
+	if cfg.ShutdownTimeout > 0 {
+		opts = append(opts, gocron.WithStopTimeout(cfg.ShutdownTimeout))
+	}
+
+	scheduler, err := gocron.NewScheduler(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create scheduler: %w", err)
 	}
 
+	switch cfg.OverlapMode {
+	case "":
+		cfg.OverlapMode = OverlapSkip
+	case OverlapSkip, OverlapQueue:
+	default:
+		return nil, fmt.Errorf("unknown overlap mode: %s", cfg.OverlapMode)
+	}
+
+	if err := validateTimeouts(cfg, scraperCfg, sites); err != nil {
+		return nil, err
+	}
+
 	srv := Service{
-		cronPattern:  cronPattern,
-		workersCount: workersCount,
-		sites:        sites,
-		scheduler:    scheduler,
-		ctx:          ctx,
-		logger:       logger,
-		scraperCfg:   scraperCfg,
-		broker:       broker,
+		cfg:        cfg,
+		sites:      sites,
+		workers:    make(chan struct{}, max(cfg.WorkersCount, 1)),
+		scheduler:  scheduler,
+		ctx:        ctx,
+		logger:     logger,
+		scraperCfg: scraperCfg,
+		robots:     scraper.NewRobotsCache(scraperCfg.RobotsCacheTTL),
+		broker:     broker,
 	}
 
 	if err := srv.initJobs(); err != nil {
@@ -62,3 +107,29 @@ func New(
 
 	return &srv, nil
 }
+
+// validateTimeouts checks that site timeouts and duration budgets fit in the run deadline,
+// otherwise they never apply and site is always stopped by the outer deadline
+func validateTimeouts(cfg Config, scraperCfg *scraper.Config, sites []Site) error {
+	for _, site := range sites {
+		limit := site.timeout(cfg.SiteTimeout)
+
+		if cfg.MaxRunDuration > 0 {
+			if limit > cfg.MaxRunDuration {
+				return fmt.Errorf("site %s timeout %s exceeds max run duration %s",
+					site.Name, limit, cfg.MaxRunDuration)
+			}
+
+			if limit == 0 {
+				limit = cfg.MaxRunDuration
+			}
+		}
+
+		budget := site.scraperConfig(scraperCfg).Budget.MaxDuration
+		if limit > 0 && budget > limit {
+			return fmt.Errorf("site %s budget max duration %s exceeds site timeout %s", site.Name, budget, limit)
+		}
+	}
+
+	return nil
+}
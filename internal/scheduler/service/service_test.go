@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keenywheels/go-spy/internal/pkg/scraper"
+)
+
+func TestValidateTimeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		budget  time.Duration
+		site    Site
+		wantErr bool
+	}{
+		{
+			name: "unlimited",
+			site: Site{Name: "site", Budget: scraper.BudgetConfig{MaxDuration: time.Hour}},
+		},
+		{
+			name: "site timeout fits in run",
+			cfg:  Config{MaxRunDuration: time.Hour, SiteTimeout: 30 * time.Minute},
+			site: Site{Name: "site", Timeout: 50 * time.Minute},
+		},
+		{
+			name:    "site timeout exceeds run",
+			cfg:     Config{MaxRunDuration: time.Hour},
+			site:    Site{Name: "site", Timeout: 3 * time.Hour},
+			wantErr: true,
+		},
+		{
+			name:    "default site timeout exceeds run",
+			cfg:     Config{MaxRunDuration: time.Hour, SiteTimeout: 2 * time.Hour},
+			site:    Site{Name: "site"},
+			wantErr: true,
+		},
+		{
+			name:    "global budget exceeds site timeout",
+			cfg:     Config{SiteTimeout: 30 * time.Minute},
+			budget:  time.Hour,
+			site:    Site{Name: "site"},
+			wantErr: true,
+		},
+		{
+			name: "site budget overrides global one",
+			cfg:  Config{SiteTimeout: 30 * time.Minute},
+			site: Site{Name: "site", Budget: scraper.BudgetConfig{MaxDuration: 20 * time.Minute}},
+		},
+		{
+			name:    "budget exceeds run without site timeout",
+			cfg:     Config{MaxRunDuration: time.Hour},
+			site:    Site{Name: "site", Budget: scraper.BudgetConfig{MaxDuration: 2 * time.Hour}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scraperCfg := scraper.DefaultConfig()
+			scraperCfg.Budget.MaxDuration = tt.budget
+
+			err := validateTimeouts(tt.cfg, scraperCfg, []Site{tt.site})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
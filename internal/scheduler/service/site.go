@@ -0,0 +1,157 @@
+package service
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/keenywheels/go-spy/internal/pkg/scraper"
+)
+
+// Site struct for site config, zero values of optional fields fall back to global config
+type Site struct {
+	Name     string `mapstructure:"name"`
+	Url      string `mapstructure:"url"`
+	Category string `mapstructure:"category"`
+
+	// CronPattern overrides global cron pattern
+	CronPattern string `mapstructure:"cron_pattern"`
+	// MaxDepth overrides scraper max depth
+	MaxDepth int `mapstructure:"max_depth"`
+	// TagsToParse overrides scraper tags to parse
+	TagsToParse []string `mapstructure:"tags_to_parse"`
+	// ExtractionMode overrides scraper extraction mode
+	ExtractionMode string `mapstructure:"extraction_mode"`
+	// OutputMode overrides scraper output mode
+	OutputMode string `mapstructure:"output_mode"`
+	// FilterPattern overrides scraper filter pattern
+	FilterPattern string `mapstructure:"filter_pattern"`
+	// Headers are added to scraper headers, site values win
+	Headers map[string]string `mapstructure:"headers"`
+	// OutputEvery overrides scraper output size
+	OutputEvery int `mapstructure:"output_every"`
+	// Timeout overrides site scraping timeout
+	Timeout time.Duration `mapstructure:"timeout"`
+	// IgnoreRobotsTxt disables robots.txt rules for site, use only with written permission of the owner
+	IgnoreRobotsTxt bool `mapstructure:"ignore_robots_txt"`
+	// AllowedDomains are added to scraper allowed domains
+	AllowedDomains []string `mapstructure:"allowed_domains"`
+	// IncludePatterns are added to scraper include patterns
+	IncludePatterns []string `mapstructure:"include_patterns"`
+	// ExcludePatterns are added to scraper exclude patterns
+	ExcludePatterns []string `mapstructure:"exclude_patterns"`
+	// Language overrides scraper stemming language
+	Language string `mapstructure:"language"`
+	// Stopwords are added to scraper custom stopwords
+	Stopwords []string `mapstructure:"stopwords"`
+	// Budget non-zero limits override scraper crawl budget
+	Budget scraper.BudgetConfig `mapstructure:"budget"`
+	// ProxyURLs overrides scraper proxy pool
+	ProxyURLs []string `mapstructure:"proxy_urls"`
+	// UseSitemap enables sitemap discovery for site
+	UseSitemap bool `mapstructure:"use_sitemap"`
+}
+
+// cronPattern returns site cron pattern or the global one
+func (site Site) cronPattern(global string) string {
+	if site.CronPattern != "" {
+		return site.CronPattern
+	}
+
+	return global
+}
+
+// timeout returns site scraping timeout or the global one
+func (site Site) timeout(global time.Duration) time.Duration {
+	if site.Timeout != 0 {
+		return site.Timeout
+	}
+
+	return global
+}
+
+// scraperConfig returns copy of global scraper config with site overrides applied
+func (site Site) scraperConfig(global *scraper.Config) *scraper.Config {
+	cfg := *global
+
+	if site.MaxDepth != 0 {
+		cfg.MaxDepth = site.MaxDepth
+	}
+
+	if len(site.TagsToParse) != 0 {
+		cfg.TagsToParse = site.TagsToParse
+	}
+
+	if site.ExtractionMode != "" {
+		cfg.ExtractionMode = site.ExtractionMode
+	}
+
+	if site.OutputMode != "" {
+		cfg.OutputMode = site.OutputMode
+	}
+
+	if site.FilterPattern != "" {
+		cfg.FilterPattern = site.FilterPattern
+	}
+
+	if len(site.Headers) != 0 {
+		headers := make(map[string]string, len(global.Headers)+len(site.Headers))
+		maps.Copy(headers, global.Headers)
+		maps.Copy(headers, site.Headers)
+		cfg.Headers = headers
+	}
+
+	if site.OutputEvery != 0 {
+		cfg.OutputEvery = site.OutputEvery
+	}
+
+	if len(site.AllowedDomains) != 0 {
+		cfg.AllowedDomains = append(slices.Clone(global.AllowedDomains), site.AllowedDomains...)
+	}
+
+	if len(site.IncludePatterns) != 0 {
+		cfg.IncludePatterns = append(slices.Clone(global.IncludePatterns), site.IncludePatterns...)
+	}
+
+	if len(site.ExcludePatterns) != 0 {
+		cfg.ExcludePatterns = append(slices.Clone(global.ExcludePatterns), site.ExcludePatterns...)
+	}
+
+	if site.Language != "" {
+		cfg.Words.Language = site.Language
+	}
+
+	if len(site.Stopwords) != 0 {
+		cfg.Words.CustomStopwords = append(slices.Clone(global.Words.CustomStopwords), site.Stopwords...)
+	}
+
+	if site.Budget.MaxPages != 0 {
+		cfg.Budget.MaxPages = site.Budget.MaxPages
+	}
+
+	if site.Budget.MaxBytes != 0 {
+		cfg.Budget.MaxBytes = site.Budget.MaxBytes
+	}
+
+	if site.Budget.MaxWords != 0 {
+		cfg.Budget.MaxWords = site.Budget.MaxWords
+	}
+
+	if site.Budget.MaxDuration != 0 {
+		cfg.Budget.MaxDuration = site.Budget.MaxDuration
+	}
+
+	if len(site.ProxyURLs) != 0 {
+		cfg.ProxyURLs = site.ProxyURLs
+	}
+
+	if site.IgnoreRobotsTxt {
+		cfg.RespectRobotsTxt = false
+	}
+
+	if site.UseSitemap {
+		cfg.Sitemap.Enabled = true
+	}
+
+	return &cfg
+}
@@ -2,51 +2,67 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/keenywheels/go-spy/internal/pkg/scraper"
 	"github.com/keenywheels/go-spy/internal/scheduler/models"
 	"golang.org/x/sync/errgroup"
 )
 
-// Site struct for site config
-type Site struct {
-	Name     string `mapstructure:"name"`
-	Url      string `mapstructure:"url"`
-	Category string `mapstructure:"category"`
-}
-
 // ScrapeTask is the task that will be executed by the scheduler
-func (s *Service) ScrapeTask() {
+func (s *Service) ScrapeTask(sites []Site) {
 	op := "Service.ScrapeTask"
 
 	sitesCh := make(chan Site)
 
-	gr, ctx := errgroup.WithContext(s.ctx)
+	// limit duration of the whole run
+	runCtx := s.ctx
+	if s.cfg.MaxRunDuration > 0 {
+		var cancel context.CancelFunc
+
+		runCtx, cancel = context.WithTimeout(s.ctx, s.cfg.MaxRunDuration)
+		defer cancel()
+	}
+
+	gr, ctx := errgroup.WithContext(runCtx)
 
 	// job producer
 	gr.Go(func() error {
-		for _, site := range s.sites {
-			sitesCh <- site
-		}
+		defer close(sitesCh)
 
-		close(sitesCh)
+		for _, site := range sites {
+			select {
+			case <-ctx.Done():
+				return nil
+			case sitesCh <- site:
+			}
+		}
 
 		return nil
 	})
 
 	scrapeStart := time.Now().Format("02-01-2006")
+	runID := uuid.New().String()
+
+	s.logger.Infof("[%s] starting scrape run %s", op, runID)
 
 	// start workers
-	for i := 0; i < s.workersCount; i++ {
+	for i := 0; i < s.cfg.WorkersCount; i++ {
 		gr.Go(func() error {
 			s.logger.Infof("[%s] starting scrape worker %d", op, i)
-			return s.scrapeWorker(ctx, i, scrapeStart, sitesCh)
+			return s.scrapeWorker(ctx, i, runID, scrapeStart, sitesCh)
 		})
 	}
 
 	if err := gr.Wait(); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			s.logger.Errorf("[%s] scrape run %s exceeded max run duration %s", op, runID, s.cfg.MaxRunDuration)
+			return
+		}
+
 		s.logger.Errorf("[%s] scrape task failed: %v", op, err)
 	}
 }
@@ -55,6 +71,7 @@ func (s *Service) ScrapeTask() {
 func (s *Service) scrapeWorker(
 	ctx context.Context,
 	workerNum int,
+	runID string,
 	start string,
 	sitesCh chan Site,
 ) error {
@@ -71,38 +88,94 @@ func (s *Service) scrapeWorker(
 				return nil
 			}
 
-			s.logger.Infof("[%s] start scraping site: %v", op, site)
-
-			scraper, err := scraper.New(s.scraperCfg)
-			if err != nil {
-				s.logger.Errorf("failed to create scraper: %v", err)
-				continue
+			// jobs with different cron patterns may run at the same time,
+			// so worker waits for free slot of the shared pool
+			select {
+			case <-ctx.Done():
+				s.logger.Infof("[%s] received done signal", op)
+				return ctx.Err()
+			case s.workers <- struct{}{}:
 			}
 
-			cb := func(msg string) {
-				s.logger.Infof("[%s] sending data to kafka", op)
-
-				if err := s.broker.SendScraperData(models.ScraperEvent{
-					SiteName: site.Name,
-					Category: site.Category,
-					Msg:      msg,
-					Date:     start,
-				}); err != nil {
-					s.logger.Errorf("[%s] failed to send data to kafka: %v", op, err)
-				}
-			}
+			s.scrapeSite(ctx, op, runID, start, site)
+			<-s.workers
+		}
+	}
+}
 
-			scraper.SetOutputCallback(cb)
-			scraper.Init(s.logger)
+// scrapeSite scrapes single site, stops when site timeout is exceeded
+func (s *Service) scrapeSite(ctx context.Context, op, runID, start string, site Site) {
+	s.logger.Infof("[%s] start scraping site: %v", op, site)
 
-			if err := scraper.VisitWithSiteName(site.Url, site.Name); err != nil {
-				s.logger.Errorf("[%s] failed to visit site %s: %v", op, site, err)
-				scraper.Flush()
+	if timeout := site.timeout(s.cfg.SiteTimeout); timeout > 0 {
+		var cancel context.CancelFunc
 
-				continue
-			}
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	sc, err := scraper.New(site.scraperConfig(s.scraperCfg))
+	if err != nil {
+		s.logger.Errorf("failed to create scraper: %v", err)
+		return
+	}
+
+	send := func(event models.ScraperEvent) {
+		s.logger.Infof("[%s] sending data to kafka", op)
+
+		event.RunID = runID
+		event.SiteName = site.Name
+		event.Category = site.Category
+		event.Date = start
 
-			scraper.Flush()
+		if err := s.broker.SendScraperData(event); err != nil {
+			s.logger.Errorf("[%s] failed to send data to kafka: %v", op, err)
 		}
 	}
+
+	sc.SetOutputCallback(func(msg, original string) {
+		send(models.ScraperEvent{Msg: msg, Original: original})
+	})
+	sc.SetDocumentCallback(func(doc scraper.Document) {
+		send(models.ScraperEvent{
+			Msg:             doc.Text,
+			URL:             doc.URL,
+			FinalURL:        doc.FinalURL,
+			Status:          doc.Status,
+			Title:           doc.Title,
+			Headings:        doc.Headings,
+			MetaDescription: doc.MetaDescription,
+			Language:        doc.Language,
+			FetchedAt:       doc.FetchedAt.Format(time.RFC3339),
+			Passages:        doc.Passages,
+		})
+	})
+	sc.SetRobotsCache(s.robots)
+	sc.SetRunID(runID)
+	sc.Init(s.logger)
+
+	err = sc.VisitWithSiteNameContext(ctx, site.Url, site.Name)
+
+	var (
+		cancelErr *scraper.CancelError
+		budgetErr *scraper.BudgetExceededError
+	)
+
+	switch {
+	case err == nil:
+		s.logger.Infof("[%s] finished scraping site %s", op, site.Name)
+	case errors.As(err, &cancelErr) && errors.Is(err, context.DeadlineExceeded):
+		s.logger.Errorf("[%s] scraping site %s was stopped by timeout", op, site.Name)
+	case errors.As(err, &cancelErr):
+		s.logger.Infof("[%s] scraping site %s was canceled", op, site.Name)
+	case errors.As(err, &budgetErr):
+		s.logger.Warnf("[%s] scraping site %s was stopped, %s limit is reached", op, site.Name, budgetErr.Limit)
+	default:
+		s.logger.Errorf("[%s] failed to visit site %s: %v", op, site, err)
+	}
+
+	for _, stat := range sc.FilterStats() {
+		s.logger.Infof("[%s] site %s: %s rule %q rejected %d links",
+			op, site.Name, stat.Kind, stat.Pattern, stat.Dropped)
+	}
 }
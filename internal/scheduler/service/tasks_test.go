@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keenywheels/go-spy/internal/pkg/scraper"
+	"github.com/keenywheels/go-spy/internal/scheduler/models"
+	"github.com/keenywheels/go-spy/pkg/logger"
+	"github.com/keenywheels/go-spy/pkg/logger/zap"
+)
+
+// testBroker records sent events
+type testBroker struct {
+	mu     sync.Mutex
+	events []models.ScraperEvent
+}
+
+func (b *testBroker) SendScraperData(event models.ScraperEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.events = append(b.events, event)
+
+	return nil
+}
+
+// testLogger records formatted messages
+type testLogger struct {
+	logger.Logger
+
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *testLogger) Infof(format string, args ...any)  { l.add(format, args...) }
+func (l *testLogger) Warnf(format string, args ...any)  { l.add(format, args...) }
+func (l *testLogger) Errorf(format string, args ...any) { l.add(format, args...) }
+
+func (l *testLogger) add(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+// contains shows whether any message contains substring
+func (l *testLogger) contains(substr string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, msg := range l.messages {
+		if strings.Contains(msg, substr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestScrapeSiteCancel(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		wantLog string
+	}{
+		{name: "canceled", wantLog: "scraping site site was canceled"},
+		{name: "site timeout", timeout: 200 * time.Millisecond, wantLog: "scraping site site was stopped by timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/":
+					fmt.Fprint(w, `<html><body><p>golang channels</p><a href="/slow">slow</a></body></html>`)
+				case "/slow":
+					if tt.timeout == 0 {
+						cancel()
+					}
+
+					select {
+					case <-r.Context().Done():
+					case <-time.After(5 * time.Second):
+					}
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer srv.Close()
+
+			scraperCfg := scraper.DefaultConfig()
+			scraperCfg.IsAsync = false
+			scraperCfg.Politeness = scraper.PolitenessConfig{HostConcurrency: 1}
+
+			l := &testLogger{Logger: zap.New(zap.LogPath(filepath.Join(t.TempDir(), "app.log")))}
+			b := &testBroker{}
+
+			s := &Service{
+				logger:     l,
+				scraperCfg: scraperCfg,
+				robots:     scraper.NewRobotsCache(scraperCfg.RobotsCacheTTL),
+				broker:     b,
+			}
+
+			s.scrapeSite(ctx, "test", "run", "start", Site{Name: "site", Url: srv.URL + "/", Timeout: tt.timeout})
+
+			if !l.contains(tt.wantLog) {
+				t.Fatalf("got log %q, want %q", l.messages, tt.wantLog)
+			}
+
+			// words collected before cancel are sent
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			if len(b.events) == 0 || !strings.Contains(b.events[0].Msg, "golang") {
+				t.Fatalf("got events %+v, want words of root page", b.events)
+			}
+
+			if event := b.events[0]; event.SiteName != "site" || event.RunID != "run" {
+				t.Fatalf("got site %s and run %s, want site and run", event.SiteName, event.RunID)
+			}
+		})
+	}
+}
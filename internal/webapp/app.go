@@ -9,9 +9,14 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/google/uuid"
 	oas "github.com/keenywheels/go-spy/internal/ogen/api/v1"
+	"github.com/keenywheels/go-spy/internal/pkg/consumer/kafka"
+	"github.com/keenywheels/go-spy/internal/pkg/index"
 	securityapi "github.com/keenywheels/go-spy/internal/webapp/delivery/http/security"
 	api "github.com/keenywheels/go-spy/internal/webapp/delivery/http/v1"
+	"github.com/keenywheels/go-spy/internal/webapp/repository/broker"
+	"github.com/keenywheels/go-spy/internal/webapp/service"
 	"github.com/keenywheels/go-spy/pkg/httpserver"
 	"github.com/keenywheels/go-spy/pkg/httputils"
 	"github.com/keenywheels/go-spy/pkg/logger"
@@ -59,8 +64,41 @@ func (app *App) Run() error {
 		}
 	}()
 
+	// create broker, index lives in memory, so every instance uses its own consumer group
+	// and replays scraper data from the oldest offset on startup
+	group := fmt.Sprintf("%s-%s", cfg.KafkaCfg.Group, uuid.New().String())
+
+	kafka, err := kafka.New(cfg.KafkaCfg.Brokers, group, kafka.Config{
+		OffsetOldest:   true,
+		Version:        cfg.KafkaCfg.Version,
+		Strategy:       cfg.KafkaCfg.Strategy,
+		SessionTimeout: cfg.KafkaCfg.SessionTimeout,
+		CommitInterval: cfg.KafkaCfg.CommitInterval,
+		RetryBackoff:   cfg.KafkaCfg.RetryBackoff,
+		Hooks:          app.rebalanceHooks(cfg.KafkaCfg.Topics.ScraperData),
+	}, app.logger)
+	if err != nil {
+		return fmt.Errorf("failed to create kafka consumer: %w", err)
+	}
+	defer func() {
+		if err := kafka.Close(); err != nil {
+			app.logger.Errorf("failed to close kafka consumer: %v", err)
+		}
+	}()
+
+	broker := broker.New(kafka, broker.Topics{
+		ScraperData: cfg.KafkaCfg.Topics.ScraperData,
+	})
+
+	// create service layer
+	srv := service.New(
+		app.logger,
+		index.New(cfg.AppCfg.SearchCfg.MaxDocsPerSite),
+		cfg.AppCfg.SearchCfg.TopTerms,
+	)
+
 	// create mux using ogen
-	mux, err := app.initRouter()
+	mux, err := app.initRouter(srv)
 	if err != nil {
 		return fmt.Errorf("failed to create http ogen server: %v", err)
 	}
@@ -78,6 +116,11 @@ func (app *App) Run() error {
 		return apiSrv.Run(ctx)
 	})
 
+	g.Go(func() error {
+		app.logger.Infof("consuming scraper data from topic %s", cfg.KafkaCfg.Topics.ScraperData)
+		return broker.ConsumeScraperData(ctx, srv.IndexScraperEvent)
+	})
+
 	if err := g.Wait(); err != nil {
 		app.logger.Error("server error: %v", err)
 		return err
@@ -86,6 +129,27 @@ func (app *App) Run() error {
 	return nil
 }
 
+// rebalanceHooks creates hooks which report partitions of scraper data topic owned by the app,
+// instance is the only member of its group, so it should own every partition
+func (app *App) rebalanceHooks(topic string) kafka.RebalanceHooks {
+	return kafka.RebalanceHooks{
+		OnAssigned: func(claims map[string][]int32) {
+			partitions := claims[topic]
+			if len(partitions) == 0 {
+				app.logger.Warnf("no partitions of topic %s assigned, index won't be filled", topic)
+				return
+			}
+
+			app.logger.Infof("indexing scraper data from partitions %v of topic %s", partitions, topic)
+		},
+		OnRevoked: func(claims map[string][]int32) {
+			if partitions := claims[topic]; len(partitions) != 0 {
+				app.logger.Infof("stopped indexing scraper data from partitions %v of topic %s", partitions, topic)
+			}
+		},
+	}
+}
+
 // initLogger create new Logger based on config
 func (app *App) initLogger() {
 	logCfg := app.cfg.AppCfg.LoggerCfg
@@ -125,7 +189,7 @@ func (app *App) initLogger() {
 }
 
 // initRouter creates router using ogen
-func (app *App) initRouter() (http.Handler, error) {
+func (app *App) initRouter(searchSrv api.ISearchService) (http.Handler, error) {
 	// prepare clients map for security handler
 	clients := make(map[string]string, len(app.cfg.AppCfg.S2SCfg.Clients))
 	for _, client := range app.cfg.AppCfg.S2SCfg.Clients {
@@ -134,7 +198,7 @@ func (app *App) initRouter() (http.Handler, error) {
 
 	// create handler
 	securityHandler := securityapi.New(clients)
-	handler := api.New()
+	handler := api.New(searchSrv)
 
 	// create custom handlers
 	notFoundHandler := func(w http.ResponseWriter, r *http.Request) {
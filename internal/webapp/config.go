@@ -40,16 +40,41 @@ type S2SConfig struct {
 	Clients []S2SClient `mapstructure:"clients"`
 }
 
+// SearchConfig contains config for search index
+type SearchConfig struct {
+	MaxDocsPerSite int `mapstructure:"max_docs_per_site"`
+	TopTerms       int `mapstructure:"top_terms"`
+}
+
 // AppConfig contains all configs which connected to main app
 type AppConfig struct {
 	HttpCfg   HttpConfig   `mapstructure:"http"`
 	LoggerCfg LoggerConfig `mapstructure:"logger"`
 	S2SCfg    S2SConfig    `mapstructure:"s2s"`
+	SearchCfg SearchConfig `mapstructure:"search"`
+}
+
+// KafkaTopics contains all kafka topics
+type KafkaTopics struct {
+	ScraperData string `mapstructure:"scraper_data"`
+}
+
+// KafkaConfig contains config for kafka
+type KafkaConfig struct {
+	Brokers        []string      `mapstructure:"brokers"`
+	Group          string        `mapstructure:"group"`
+	Version        string        `mapstructure:"version"`
+	Strategy       string        `mapstructure:"strategy"`
+	SessionTimeout time.Duration `mapstructure:"session_timeout"`
+	CommitInterval time.Duration `mapstructure:"commit_interval"`
+	RetryBackoff   time.Duration `mapstructure:"retry_backoff"`
+	Topics         KafkaTopics   `mapstructure:"topics"`
 }
 
 // Config global config, contains all configs
 type Config struct {
-	AppCfg AppConfig `mapstructure:"app"`
+	AppCfg   AppConfig   `mapstructure:"app"`
+	KafkaCfg KafkaConfig `mapstructure:"kafka"`
 }
 
 // LoadConfig function which reads config file and return Config instance
@@ -1,14 +1,26 @@
 package http
 
-import gen "github.com/keenywheels/go-spy/internal/ogen/api/v1"
+import (
+	"context"
+
+	gen "github.com/keenywheels/go-spy/internal/ogen/api/v1"
+)
 
 var _ gen.Handler = (*Controller)(nil)
 
+// ISearchService represents search service interface
+type ISearchService interface {
+	Search(ctx context.Context, site string, size, count int) ([]string, error)
+}
+
 // Controller contains http handlers
 type Controller struct {
+	srv ISearchService
 }
 
 // New creates new controller instance
-func New() *Controller {
-	return &Controller{}
+func New(srv ISearchService) *Controller {
+	return &Controller{
+		srv: srv,
+	}
 }
@@ -9,13 +9,19 @@ import (
 	"github.com/keenywheels/go-spy/pkg/httputils"
 )
 
-// StartSearch TODO: заменить мок
+// default values of optional request fields
+const (
+	defaultMessageSize  = 100
+	defaultMessageCount = 10
+)
+
+// StartSearch returns messages collected from the requested site
 func (c *Controller) StartSearch(
 	ctx context.Context,
 	req *gen.StartSearchRequest,
 	params gen.StartSearchParams,
 ) (gen.StartSearchRes, error) {
-	op := "Controller.GetAllInterest"
+	op := "Controller.StartSearch"
 	log := ctxutils.GetLogger(ctx)
 
 	// validate that client using his token
@@ -39,18 +45,19 @@ func (c *Controller) StartSearch(
 
 	log.Infof("[%s] got start search request for search: %+v", op, req)
 
-	resp := gen.StartSearchOKApplicationJSON(getMockData())
+	msgs, err := c.srv.Search(ctx, req.Site, req.MessageSize.Or(defaultMessageSize), req.MessageCount.Or(defaultMessageCount))
+	if err != nil {
+		log.Errorf("[%s] failed to search messages: %v", op, err)
 
-	return &resp, nil
-}
+		return &gen.StartSearchInternalServerError{
+			Error: httputils.ErrorInternalError,
+		}, nil
+	}
 
-// getMockData simply returns some mock data
-func getMockData() []gen.SearchMessage {
-	return []gen.SearchMessage{
-		{Message: "Boy favourable day can introduced sentiments entreaties. Noisier carried of in warrant because. So mr plate seems cause chief widen first. Two differed husbands met screened his. Bed was form wife out ask draw. Wholly coming at we no enable. Offending sir delivered questions now new met. Acceptance she interested new boisterous day discretion celebrated."},
-		{Message: "Spot of come to ever hand as lady meet on. Delicate contempt received two yet advanced. Gentleman as belonging he commanded believing dejection in by. On no am winding chicken so behaved. Its preserved sex enjoyment new way behaviour. Him yet devonshire celebrated especially. Unfeeling one provision are smallness resembled repulsive."},
-		{Message: "Manor we shall merit by chief wound no or would. Oh towards between subject passage sending mention or it. Sight happy do burst fruit to woody begin at. Assurance perpetual he in oh determine as. The year paid met him does eyes same. Own marianne improved sociable not out. Thing do sight blush mr an. Celebrated am announcing delightful remarkably we in literature it solicitude. Design use say piqued any gay supply. Front sex match vexed her those great."},
-		{Message: "Started earnest brother believe an exposed so. Me he believing daughters if forfeited at furniture. Age again and stuff downs spoke. Late hour new nay able fat each sell. Nor themselves age introduced frequently use unsatiable devonshire get. They why quit gay cold rose deal park. One same they four did ask busy. Reserved opinions fat him nay position. Breakfast as zealously incommode do agreeable furniture. One too nay led fanny allow plate."},
-		{Message: "Examine she brother prudent add day ham. Far stairs now coming bed oppose hunted become his. You zealously departure had procuring suspicion. Books whose front would purse if be do decay. Quitting you way formerly disposed perceive ladyship are. Common turned boy direct and yet."},
+	resp := make(gen.StartSearchOKApplicationJSON, 0, len(msgs))
+	for _, msg := range msgs {
+		resp = append(resp, gen.SearchMessage{Message: msg})
 	}
+
+	return &resp, nil
 }
@@ -0,0 +1,13 @@
+package broker
+
+import (
+	"context"
+
+	"github.com/keenywheels/go-spy/internal/pkg/consumer/kafka"
+	"github.com/keenywheels/go-spy/internal/webapp/models"
+)
+
+// ConsumeScraperData consumes scraper data topic until context is done
+func (b *Broker) ConsumeScraperData(ctx context.Context, h kafka.TypedHandler[models.ScraperEvent]) error {
+	return b.kafka.Consume(ctx, []string{b.topics.ScraperData}, kafka.JSONHandler(h))
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+
+	"github.com/keenywheels/go-spy/internal/pkg/index"
+	"github.com/keenywheels/go-spy/internal/webapp/models"
+)
+
+// IndexScraperEvent adds scraped data to the site index
+func (s *Service) IndexScraperEvent(_ context.Context, event models.ScraperEvent) error {
+	if event.SiteName == "" || event.Msg == "" {
+		return nil
+	}
+
+	s.index.AddDocument(event.SiteName, index.Document{
+		Text:     event.Msg,
+		Date:     event.Date,
+		URL:      event.FinalURL,
+		Title:    event.Title,
+		Passages: event.Passages,
+	})
+	s.logger.Debugf("[Service.IndexScraperEvent] indexed data for site %s, documents=%d",
+		event.SiteName, s.index.Size(event.SiteName))
+
+	return nil
+}
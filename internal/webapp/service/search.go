@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/keenywheels/go-spy/internal/pkg/index"
+	"github.com/keenywheels/go-spy/internal/pkg/nlp"
+)
+
+// chunk represents part of the document which can be returned as message
+type chunk struct {
+	text  string
+	score int
+}
+
+// Search returns up to count messages of the site, each message is at most size characters long
+func (s *Service) Search(_ context.Context, site string, size, count int) ([]string, error) {
+	terms := s.index.TopTerms(site, s.topTerms)
+	if len(terms) == 0 {
+		return []string{}, nil
+	}
+
+	termSet := make(map[string]struct{}, len(terms))
+	for _, t := range terms {
+		termSet[t] = struct{}{}
+	}
+
+	// rank chunks of the best documents by number of significant terms
+	chunks := make([]chunk, 0, count)
+	for _, hit := range s.index.Search(site, terms, count) {
+		for _, text := range messages(hit.Document, size) {
+			score := 0
+			for _, t := range index.Tokenize(text) {
+				if _, ok := termSet[t]; ok {
+					score++
+				}
+			}
+
+			chunks = append(chunks, chunk{text: text, score: score})
+		}
+	}
+
+	sort.SliceStable(chunks, func(i, j int) bool {
+		return chunks[i].score > chunks[j].score
+	})
+
+	if len(chunks) > count {
+		chunks = chunks[:count]
+	}
+
+	msgs := make([]string, 0, len(chunks))
+	for _, c := range chunks {
+		msgs = append(msgs, c.text)
+	}
+
+	return msgs, nil
+}
+
+// messages splits document into messages which are at most size characters long,
+// messages end at sentence ends when possible
+func messages(doc index.Document, size int) []string {
+	if len(doc.Passages) == 0 {
+		return nlp.Cut(doc.Text, size)
+	}
+
+	msgs := make([]string, 0, len(doc.Passages))
+	for _, p := range doc.Passages {
+		msgs = append(msgs, nlp.Cut(p, size)...)
+	}
+
+	return msgs
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/keenywheels/go-spy/internal/pkg/index"
+	"github.com/keenywheels/go-spy/internal/webapp/models"
+	"github.com/keenywheels/go-spy/pkg/logger/zap"
+)
+
+// newTestService creates service which indexed specified events
+func newTestService(t *testing.T, events ...models.ScraperEvent) *Service {
+	t.Helper()
+
+	s := New(zap.New(zap.LogPath(filepath.Join(t.TempDir(), "app.log"))), index.New(10), 0)
+	for _, event := range events {
+		if err := s.IndexScraperEvent(context.Background(), event); err != nil {
+			t.Fatalf("failed to index event: %v", err)
+		}
+	}
+
+	return s
+}
+
+func TestSearch(t *testing.T) {
+	s := newTestService(t,
+		models.ScraperEvent{SiteName: "site", Msg: "Go is fast. Go is simple. Cats sleep."},
+		models.ScraperEvent{SiteName: "site", Msg: "Rust is safe."},
+		models.ScraperEvent{SiteName: "empty"},
+	)
+
+	tests := []struct {
+		name  string
+		site  string
+		size  int
+		count int
+		want  []string
+	}{
+		{name: "unknown site", site: "other", size: 100, count: 10, want: []string{}},
+		{name: "site without documents", site: "empty", size: 100, count: 10, want: []string{}},
+		{
+			name:  "chunks are ranked by significant terms",
+			site:  "site",
+			size:  30,
+			count: 10,
+			want:  []string{"Go is fast. Go is simple.", "Rust is safe.", "Cats sleep."},
+		},
+		{name: "count", site: "site", size: 30, count: 1, want: []string{"Go is fast. Go is simple."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Search(context.Background(), tt.site, tt.size, tt.count)
+			if err != nil {
+				t.Fatalf("failed to search: %v", err)
+			}
+
+			if got == nil || !slices.Equal(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessages(t *testing.T) {
+	text := "Go is fast. It is simple! Is it fun? Yes."
+
+	tests := []struct {
+		name string
+		doc  index.Document
+		size int
+		want []string
+	}{
+		{
+			name: "text is cut by sentences",
+			doc:  index.Document{Text: text},
+			size: 25,
+			want: []string{"Go is fast. It is simple!", "Is it fun? Yes."},
+		},
+		{
+			name: "passages aren't joined",
+			doc:  index.Document{Text: text, Passages: []string{"Go is fast.", "It is simple! Is it fun?", "Yes."}},
+			size: 25,
+			want: []string{"Go is fast.", "It is simple! Is it fun?", "Yes."},
+		},
+		{
+			name: "long passage is cut",
+			doc:  index.Document{Text: text, Passages: []string{"Go is fast. It is simple!", "Is it fun? Yes."}},
+			size: 12,
+			want: []string{"Go is fast.", "It is", "simple!", "Is it fun?", "Yes."},
+		},
+		{
+			name: "unlimited size",
+			doc:  index.Document{Text: text},
+			size: 0,
+			want: []string{"Go is fast.", "It is simple!", "Is it fun?", "Yes."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messages(tt.doc, tt.size); !slices.Equal(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
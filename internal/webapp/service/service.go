@@ -0,0 +1,29 @@
+package service
+
+import (
+	"github.com/keenywheels/go-spy/internal/pkg/index"
+	"github.com/keenywheels/go-spy/pkg/logger"
+)
+
+// defaultTopTerms default number of site terms used to rank messages
+const defaultTopTerms = 20
+
+// Service represent service layer of the application
+type Service struct {
+	logger   logger.Logger
+	index    *index.Index
+	topTerms int
+}
+
+// New creates new service instance
+func New(logger logger.Logger, index *index.Index, topTerms int) *Service {
+	if topTerms <= 0 {
+		topTerms = defaultTopTerms
+	}
+
+	return &Service{
+		logger:   logger,
+		index:    index,
+		topTerms: topTerms,
+	}
+}